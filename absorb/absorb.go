@@ -0,0 +1,165 @@
+// Package absorb splits a diff into hunks and rebuilds patches from a subset of them, so that each hunk
+// can be committed as a fixup of the commit that last changed its lines.
+package absorb
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Hunk is a change to a contiguous range of lines in a file, from a diff without context lines (-U0).
+type Hunk struct {
+	File string
+	// The first line that the hunk removes from the old file. If OldCount is 0, lines are added
+	// after this line instead, where 0 means the start of the file.
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	// The removed and added lines, including their - and + prefixes.
+	Lines []string
+}
+
+// BlameRange returns the range of lines in the old file that decide which commit the hunk belongs to.
+// Added lines are attributed to the line above them, or to the first line of the file.
+func (h Hunk) BlameRange() (int, int) {
+	if h.OldCount > 0 {
+		return h.OldStart, h.OldStart + h.OldCount - 1
+	}
+	return max(h.OldStart, 1), max(h.OldStart, 1)
+}
+
+// Skipped is a file in the diff that has no hunks that can be absorbed, e.g. a new or renamed file.
+type Skipped struct {
+	File   string
+	Reason string
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseDiff parses the hunks of a diff generated with -U0 and the default a/ and b/ prefixes.
+// Only files that exist on both sides with the same name and mode can be absorbed, others are skipped.
+func ParseDiff(diff string) ([]Hunk, []Skipped, error) {
+	var hunks []Hunk
+	var skipped []Skipped
+	var file string
+	var skipReason string
+	var curr *Hunk
+
+	flush := func() {
+		if curr != nil {
+			hunks = append(hunks, *curr)
+			curr = nil
+		}
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			if file != "" && skipReason != "" {
+				skipped = append(skipped, Skipped{File: file, Reason: skipReason})
+			}
+			parts := strings.SplitN(strings.TrimPrefix(line, "diff --git a/"), " b/", 2)
+			file = parts[0]
+			skipReason = ""
+		case skipReason != "":
+			continue
+		case strings.HasPrefix(line, "new file mode"):
+			skipReason = "new file"
+		case strings.HasPrefix(line, "deleted file mode"):
+			skipReason = "deleted file"
+		case strings.HasPrefix(line, "rename from"), strings.HasPrefix(line, "copy from"):
+			skipReason = "renamed file"
+		case strings.HasPrefix(line, "old mode"):
+			skipReason = "mode change"
+		case strings.HasPrefix(line, "Binary files"):
+			skipReason = "binary file"
+		case strings.HasPrefix(line, "@@ "):
+			flush()
+			m := hunkHeaderRegex.FindStringSubmatch(line)
+			if m == nil {
+				return nil, nil, fmt.Errorf("invalid hunk header: %s", line)
+			}
+			curr = &Hunk{
+				File:     file,
+				OldStart: atoi(m[1]),
+				OldCount: atoiOr(m[2], 1),
+				NewStart: atoi(m[3]),
+				NewCount: atoiOr(m[4], 1),
+			}
+		case curr != nil && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, `\`)):
+			curr.Lines = append(curr.Lines, line)
+		}
+	}
+	flush()
+	if file != "" && skipReason != "" {
+		skipped = append(skipped, Skipped{File: file, Reason: skipReason})
+	}
+	// Hunks of files that were later skipped, e.g. after a mode change, can't be applied on their own.
+	hunks = slices.DeleteFunc(hunks, func(h Hunk) bool {
+		return slices.ContainsFunc(skipped, func(s Skipped) bool { return s.File == h.File })
+	})
+	return hunks, skipped, nil
+}
+
+// Patch formats hunks as a patch that applies with git apply --unidiff-zero, after the hunks in
+// applied were already applied to the file. Hunks from the same diff don't overlap, so the applied
+// hunks only shift the lines below them.
+func Patch(hunks []Hunk, applied []Hunk) string {
+	hunks = slices.Clone(hunks)
+	slices.SortStableFunc(hunks, func(a, b Hunk) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		return a.OldStart - b.OldStart
+	})
+
+	var sb strings.Builder
+	var file string
+	// The net number of lines added by earlier hunks in this patch.
+	var delta int
+	for _, h := range hunks {
+		if h.File != file {
+			file = h.File
+			delta = 0
+			fmt.Fprintf(&sb, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", file, file, file, file)
+		}
+		oldStart := h.OldStart
+		for _, a := range applied {
+			if a.File == h.File && a.OldStart < h.OldStart {
+				oldStart += a.NewCount - a.OldCount
+			}
+		}
+		newStart := oldStart + delta
+		switch {
+		case h.OldCount == 0:
+			// Lines are added after oldStart.
+			newStart++
+		case h.NewCount == 0:
+			// Lines are removed after newStart.
+			newStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, h.OldCount, newStart, h.NewCount)
+		for _, l := range h.Lines {
+			sb.WriteString(l + "\n")
+		}
+		delta += h.NewCount - h.OldCount
+	}
+	return sb.String()
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// atoiOr returns def if s is empty, since hunk headers omit counts of 1.
+func atoiOr(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	return atoi(s)
+}
@@ -0,0 +1,144 @@
+package absorb_test
+
+import (
+	"testing"
+
+	"github.com/raymondji/git-stack-cli/absorb"
+	"github.com/stretchr/testify/require"
+)
+
+const diff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3 +3 @@ package main
+-var a = 1
++var a = 2
+@@ -10,2 +9,0 @@ func f() {
+-	x()
+-	y()
+@@ -20,0 +19,2 @@ func g() {
++	z()
++	w()
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package main
+diff --git a/script.sh b/script.sh
+old mode 100644
+new mode 100755
+index 4444444..5555555
+--- a/script.sh
++++ b/script.sh
+@@ -1 +1 @@
+-echo a
++echo b
+diff --git a/README.md b/README.md
+index 6666666..7777777 100644
+--- a/README.md
++++ b/README.md
+@@ -5,0 +6 @@ intro
++more
+\ No newline at end of file
+`
+
+func TestParseDiff(t *testing.T) {
+	hunks, skipped, err := absorb.ParseDiff(diff)
+	require.NoError(t, err)
+	require.Equal(t, []absorb.Hunk{
+		{File: "main.go", OldStart: 3, OldCount: 1, NewStart: 3, NewCount: 1, Lines: []string{"-var a = 1", "+var a = 2"}},
+		{File: "main.go", OldStart: 10, OldCount: 2, NewStart: 9, NewCount: 0, Lines: []string{"-\tx()", "-\ty()"}},
+		{File: "main.go", OldStart: 20, OldCount: 0, NewStart: 19, NewCount: 2, Lines: []string{"+\tz()", "+\tw()"}},
+		{File: "README.md", OldStart: 5, OldCount: 0, NewStart: 6, NewCount: 1, Lines: []string{"+more", `\ No newline at end of file`}},
+	}, hunks)
+	require.Equal(t, []absorb.Skipped{
+		{File: "new.go", Reason: "new file"},
+		{File: "script.sh", Reason: "mode change"},
+	}, skipped)
+}
+
+func TestBlameRange(t *testing.T) {
+	cases := map[string]struct {
+		hunk               absorb.Hunk
+		wantStart, wantEnd int
+	}{
+		"modified":           {hunk: absorb.Hunk{OldStart: 3, OldCount: 2, NewCount: 1}, wantStart: 3, wantEnd: 4},
+		"added":              {hunk: absorb.Hunk{OldStart: 7, OldCount: 0, NewCount: 1}, wantStart: 7, wantEnd: 7},
+		"added at beginning": {hunk: absorb.Hunk{OldStart: 0, OldCount: 0, NewCount: 1}, wantStart: 1, wantEnd: 1},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			start, end := c.hunk.BlameRange()
+			require.Equal(t, c.wantStart, start)
+			require.Equal(t, c.wantEnd, end)
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	hunks, _, err := absorb.ParseDiff(diff)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		hunks   []absorb.Hunk
+		applied []absorb.Hunk
+		want    string
+	}{
+		"all hunks": {
+			hunks: hunks[:3],
+			want: `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,1 +3,1 @@
+-var a = 1
++var a = 2
+@@ -10,2 +9,0 @@
+-	x()
+-	y()
+@@ -20,0 +19,2 @@
++	z()
++	w()
+`,
+		},
+		"after earlier hunks were applied": {
+			hunks:   hunks[2:3],
+			applied: hunks[:2],
+			want: `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -18,0 +19,2 @@
++	z()
++	w()
+`,
+		},
+		"after later hunks were applied": {
+			hunks:   hunks[:1],
+			applied: hunks[1:3],
+			want: `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,1 +3,1 @@
+-var a = 1
++var a = 2
+`,
+		},
+		"no newline at end of file": {
+			hunks: hunks[3:],
+			want: `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -5,0 +6,1 @@
++more
+\ No newline at end of file
+`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.want, absorb.Patch(c.hunks, c.applied))
+		})
+	}
+}
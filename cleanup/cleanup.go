@@ -0,0 +1,58 @@
+// Package cleanup deletes branches along with their change requests.
+package cleanup
+
+import (
+	"context"
+	"errors"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+)
+
+type Branch struct {
+	Name string
+	// Zero value if the branch has no open change request.
+	ChangeRequest githost.PullRequest
+}
+
+type Opts struct {
+	// The remote to delete branches from.
+	PushRemote string
+	// If true, remote branches are not deleted.
+	KeepRemote bool
+}
+
+// Plan looks up the open change request for each branch in repoPath.
+func Plan(host githost.Host, repoPath string, names ...string) ([]Branch, error) {
+	return concurrent.Map(context.Background(), names, func(ctx context.Context, name string) (Branch, error) {
+		cr, err := host.GetChangeReqeuest(repoPath, name)
+		if errors.Is(err, githost.ErrDoesNotExist) {
+			return Branch{Name: name}, nil
+		} else if err != nil {
+			return Branch{}, err
+		}
+		return Branch{Name: name, ChangeRequest: cr}, nil
+	})
+}
+
+// Run closes the change request of each branch, then deletes the branch locally and remotely.
+func Run(git libgit.Git, host githost.Host, repoPath string, branches []Branch, opts Opts) error {
+	for _, b := range branches {
+		if b.ChangeRequest.ID != 0 {
+			if _, err := host.CloseChangeRequest(repoPath, b.ChangeRequest); err != nil {
+				return err
+			}
+		}
+		if err := git.DeleteBranchIfExists(b.Name); err != nil {
+			return err
+		}
+		if opts.KeepRemote {
+			continue
+		}
+		if err := git.DeleteRemoteBranchIfExists(opts.PushRemote, b.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
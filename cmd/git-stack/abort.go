@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var abortCmd = &cobra.Command{
+	Use:   "abort",
+	Short: "Abort a rebase that stopped on a conflict",
+	Long:  "Returns every branch in the stack to where it was before the rebase started.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git := deps.git
+
+		if _, ok, err := git.GetRebaseState(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("no rebase in progress")
+		}
+		if err := git.RebaseAbort(); err != nil {
+			return err
+		}
+		if err := clearFollowUp(git); err != nil {
+			return err
+		}
+
+		fmt.Println("Aborted rebase")
+		fmt.Println()
+		return printBranches(deps, "")
+	},
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/absorb"
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var absorbRebaseFlag bool
+
+func init() {
+	absorbCmd.Flags().BoolVarP(&absorbRebaseFlag, "rebase", "r", false, "Automatically perform a git rebase")
+}
+
+var absorbCmd = &cobra.Command{
+	Use:   "absorb",
+	Short: "Create fixup commits for the staged changes in the commits they change",
+	Long: "For each staged hunk, finds the commit in the current stack that last changed the hunk's lines " +
+		"and creates a fixup commit for it. Added lines belong to the commit that last changed the line above them. " +
+		"Hunks that change lines from several commits, or from commits outside the stack, are left staged.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, theme := deps.git, deps.repoCfg.DefaultBranch, deps.theme
+
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		stack, err := stackparser.GetCurrent(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		forkPoint, err := git.GetMergeBase(stack.Base, "HEAD")
+		if err != nil {
+			return err
+		}
+		log, err := git.Log(forkPoint, "HEAD")
+		if err != nil {
+			return err
+		}
+		// The branch that each commit belongs to is the nearest branch above it.
+		commitBranches := map[string]string{}
+		var branch string
+		for _, c := range log.Commits {
+			if len(c.LocalBranches) > 0 {
+				branch = c.LocalBranches[0]
+			}
+			commitBranches[c.Hash] = branch
+		}
+
+		diff, err := git.GetStagedDiff()
+		if err != nil {
+			return err
+		}
+		hunks, skipped, err := absorb.ParseDiff(diff)
+		if err != nil {
+			return err
+		}
+		if len(hunks) == 0 && len(skipped) == 0 {
+			return fmt.Errorf("no staged changes to absorb")
+		}
+
+		var leftStaged []string
+		for _, s := range skipped {
+			leftStaged = append(leftStaged, fmt.Sprintf("%s: %s", s.File, s.Reason))
+		}
+		// Keys are short commit hashes
+		targets := map[string][]absorb.Hunk{}
+		for _, h := range hunks {
+			start, end := h.BlameRange()
+			location := fmt.Sprintf("%s:%d", h.File, start)
+			if end != start {
+				location += fmt.Sprintf("-%d", end)
+			}
+			hashes, err := git.BlameLines("HEAD", h.File, start, end)
+			if err != nil {
+				leftStaged = append(leftStaged, location+": could not find the commit that changed these lines")
+				continue
+			}
+			slices.Sort(hashes)
+			hashes = slices.Compact(hashes)
+			if len(hashes) > 1 {
+				leftStaged = append(leftStaged, location+": changes lines from more than one commit")
+				continue
+			}
+			idx := slices.IndexFunc(log.Commits, func(c libgit.Commit) bool {
+				return strings.HasPrefix(hashes[0], c.Hash)
+			})
+			if idx == -1 {
+				leftStaged = append(leftStaged, location+": changes lines from outside the stack")
+				continue
+			}
+			hash := log.Commits[idx].Hash
+			targets[hash] = append(targets[hash], h)
+		}
+
+		if len(targets) == 0 {
+			printLeftStaged(leftStaged, theme)
+			return fmt.Errorf("none of the staged changes could be absorbed")
+		}
+		// The rebase needs a clean repo, so check before creating any fixup commits.
+		if absorbRebaseFlag {
+			if len(leftStaged) > 0 {
+				printLeftStaged(leftStaged, theme)
+				return fmt.Errorf("cannot rebase, some of the staged changes can't be absorbed")
+			}
+			if ok, err := git.HasUnstagedChanges(); err != nil {
+				return err
+			} else if ok {
+				return fmt.Errorf("cannot rebase, git repo has unstaged changes")
+			}
+		}
+
+		// Commit each fixup from only its own hunks, then restore the full index so that the
+		// hunks that weren't absorbed stay staged.
+		staged, err := git.WriteTree()
+		if err != nil {
+			return err
+		}
+		var applied []absorb.Hunk
+		var absorbed []string
+		// From the bottom of the stack.
+		for i := len(log.Commits) - 1; i >= 0; i-- {
+			c := log.Commits[i]
+			hs, ok := targets[c.Hash]
+			if !ok {
+				continue
+			}
+			err := git.ReadTree("HEAD")
+			if err == nil {
+				err = git.ApplyToIndex(absorb.Patch(hs, applied))
+			}
+			if err == nil {
+				_, err = git.CommitFixup(c.Hash, false)
+			}
+			if err != nil {
+				if restoreErr := git.ReadTree(staged); restoreErr != nil {
+					return fmt.Errorf("%v, and failed to restore the staged changes, err: %v", err, restoreErr)
+				}
+				return err
+			}
+			applied = append(applied, hs...)
+			absorbed = append(absorbed, fmt.Sprintf("%s %s (%s)", c.Hash, c.Subject, commitBranches[c.Hash]))
+		}
+		if err := git.ReadTree(staged); err != nil {
+			return err
+		}
+
+		fmt.Printf("Absorbed %d hunks into fixup commits for:\n", len(applied))
+		for _, line := range absorbed {
+			fmt.Println(strings.Repeat(" ", 8) + line)
+		}
+		if !absorbRebaseFlag {
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack rebase --keep-base" to squash the fixup commits)`)
+		}
+		printLeftStaged(leftStaged, theme)
+		if !absorbRebaseFlag {
+			return nil
+		}
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		res, err := git.Rebase(stack.Base, libgit.RebaseOpts{
+			Autosquash: true,
+			UpdateRefs: true,
+			KeepBase:   true,
+		})
+		if err != nil {
+			return handleRebaseErr(git, theme, err)
+		}
+		fmt.Println(res)
+		return nil
+	},
+}
+
+func printLeftStaged(leftStaged []string, theme config.Theme) {
+	if len(leftStaged) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Changes left staged:")
+	for _, line := range leftStaged {
+		fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(line))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var baseRebaseFlag bool
+
+func init() {
+	baseCmd.Flags().BoolVarP(&baseRebaseFlag, "rebase", "r", false, "Rebase the stack onto the new base")
+}
+
+var baseCmd = &cobra.Command{
+	Use:   "base [newbase]",
+	Short: "Show or change the base branch of the current stack",
+	Long: "Stacks are based on the default branch unless configured otherwise, e.g. to target a release branch. " +
+		"The base is stored in git config (branch.<name>.stackBase) for every branch in the stack.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
+
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		tree, err := stackparser.GetCurrentTree(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		oldBase := tree.Base()
+		if len(args) == 0 {
+			fmt.Printf("Current stack is based on %s\n", oldBase)
+			return nil
+		}
+
+		newBase := args[0]
+		if _, err := git.GetShortCommitHash(newBase); err != nil {
+			return fmt.Errorf("no branch named: %s", newBase)
+		}
+		branches := tree.Branches()
+		for _, b := range branches {
+			if b == newBase {
+				return fmt.Errorf("cannot base the stack on its own branch %s", newBase)
+			}
+		}
+		if baseRebaseFlag {
+			if ok, err := git.IsRepoClean(); err != nil {
+				return err
+			} else if !ok {
+				return fmt.Errorf("aborting, git repo has changes")
+			}
+			if !tree.IsLinear() {
+				return fmt.Errorf("cannot rebase stacks that share branches with other stacks")
+			}
+			if err := snapshotBranches(git); err != nil {
+				return err
+			}
+		}
+
+		// Record the new base before rebasing, so that it is already in place if the rebase
+		// stops on a conflict.
+		for _, b := range branches {
+			if newBase == defaultBranch {
+				err = git.UnsetStackBase(b)
+			} else {
+				err = git.SetStackBase(b, newBase)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Set the base of %s to %s\n", tree.Stacks[0].Name, newBase)
+		if oldBase == newBase {
+			return nil
+		}
+
+		top := tree.Stacks[0].Name
+		if !baseRebaseFlag {
+			fmt.Println(strings.Repeat(" ", 2) + fmt.Sprintf(
+				`(use "git rebase --onto %s %s %s --update-refs" to move the stack's commits onto %s)`,
+				newBase, oldBase, top, newBase))
+			return nil
+		}
+		_, err = git.Rebase(oldBase, libgit.RebaseOpts{
+			Onto:       newBase,
+			Branch:     top,
+			UpdateRefs: true,
+		})
+		if err != nil {
+			return handleRebaseErr(git, deps.theme, err)
+		}
+		fmt.Printf("Successfully rebased %s on %s\n", top, newBase)
+		return nil
+	},
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"slices"
 
-	"github.com/charmbracelet/huh/spinner"
 	"github.com/raymondji/git-stack-cli/concurrent"
 	"github.com/raymondji/git-stack-cli/githost"
 	"github.com/raymondji/git-stack-cli/stackparser"
@@ -19,6 +17,7 @@ var branchPRsFlag bool
 func init() {
 	branchCmd.Flags().BoolVar(&branchPRsFlag, "prs", false, "Whether to show PRs for each branch")
 	branchCmd.Flags().BoolVar(&branchPRsFlag, "mrs", false, "Whether to show MRs for each branch")
+	addJSONFlag(branchCmd)
 }
 
 var branchCmd = &cobra.Command{
@@ -35,124 +34,162 @@ var branchCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		git, defaultBranch, host, theme := deps.git, deps.repoCfg.DefaultBranch, deps.host, deps.theme
-		benchmarkPoint("listCmd", "got deps")
+		var stackName string
+		if len(args) == 1 {
+			stackName = args[0]
+		}
+		return printBranches(deps, stackName)
+	},
+}
 
-		var currBranch, currCommit string
-		var stacks []stackparser.Stack
-		var mergedBranches []string
-		err = concurrent.Run(
-			context.Background(),
-			func(ctx context.Context) error {
-				var err error
-				currCommit, err = git.GetShortCommitHash("HEAD")
-				return err
-			},
-			func(ctx context.Context) error {
-				var err error
-				mergedBranches, err = git.GetMergedBranches(defaultBranch)
-				return err
-			},
-			func(ctx context.Context) error {
-				var err error
-				currBranch, err = git.GetCurrentBranch()
-				return err
-			},
-			func(ctx context.Context) error {
-				log, err := git.LogAll(defaultBranch)
-				if err != nil {
-					return err
-				}
-				stacks, err = stackparser.ParseStacks(log)
-				return err
-			},
-		)
-		if err != nil {
+// printBranches prints the branches of the named stack, or of the current stack if stackName is empty.
+func printBranches(deps deps, stackName string) error {
+	git, defaultBranch, host, theme := deps.git, deps.repoCfg.DefaultBranch, deps.host, deps.theme
+	benchmarkPoint("listCmd", "got deps")
+
+	var currBranch, currCommit string
+	var stacks []stackparser.Stack
+	var mergedInto map[string]string
+	var squashMergedBranches []string
+	err := concurrent.Run(
+		context.Background(),
+		func(ctx context.Context) error {
+			var err error
+			currCommit, err = git.GetShortCommitHash("HEAD")
 			return err
-		}
-		benchmarkPoint("listCmd", "got curr commit, curr branch, and stack stackparser")
+		},
+		func(ctx context.Context) error {
+			var err error
+			mergedInto, err = getMergedBranches(git, defaultBranch)
+			return err
+		},
+		func(ctx context.Context) error {
+			var err error
+			currBranch, err = git.GetCurrentBranch()
+			return err
+		},
+		func(ctx context.Context) error {
+			var err error
+			stacks, squashMergedBranches, err = parseStacks(git, defaultBranch)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+	benchmarkPoint("listCmd", "got curr commit, curr branch, and stack stackparser")
 
-		var stack stackparser.Stack
-		if len(args) == 0 {
-			if slices.Contains(mergedBranches, currBranch) {
-				fmt.Printf("error: the current branch is not a valid stack (it's merged into %s)\n", defaultBranch)
-				return nil
+	var tree stackparser.Tree
+	if stackName == "" {
+		if msg := mergedError(currBranch, defaultBranch, mergedInto, squashMergedBranches); msg != "" {
+			if jsonFlag {
+				return errors.New(msg)
 			}
-			stack, err = stackparser.GetCurrent(stacks, currCommit)
-			if err != nil {
-				return err
-			}
-		} else {
-			wantStack := args[0]
-			var found bool
-			for _, s := range stacks {
-				if s.Name == wantStack {
-					stack = s
-					found = true
-				}
-			}
-			if !found {
-				return fmt.Errorf("no stack named: %s", wantStack)
+			fmt.Println("error: " + msg)
+			return nil
+		}
+		tree, err = stackparser.GetCurrentTree(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+	} else {
+		var found bool
+		for _, s := range stacks {
+			if s.Name == stackName {
+				tree = stackparser.GetTree(stacks, s)
+				found = true
 			}
 		}
-		defer func() {
-			printProblems([]stackparser.Stack{stack}, deps.theme)
-		}()
-		benchmarkPoint("listCmd", "got desired stack")
+		if !found {
+			return fmt.Errorf("no stack named: %s", stackName)
+		}
+	}
+	defer func() {
+		if !jsonFlag {
+			printProblems(tree.Stacks, deps.theme)
+		}
+	}()
+	benchmarkPoint("listCmd", "got desired stack")
 
-		totalOrder := true
-		branches, err := stack.TotalOrderedBranches()
-		var errNoTotalOrder stackparser.NoTotalOrderError
-		if errors.As(err, &errNoTotalOrder) {
-			// TODO: check for this specific error type
-			fmt.Printf("Warning: stack %s does not have a total order\n", stack.Name)
+	totalOrder := true
+	var branches []string
+	var parents map[string]string
+	var errNoTotalOrder stackparser.NoTotalOrderError
+	if tree.IsLinear() {
+		branches, err = tree.Stacks[0].TotalOrderedBranches()
+	} else {
+		branches = tree.Branches()
+		parents, err = tree.Parents()
+	}
+	if errors.As(err, &errNoTotalOrder) {
+		if !jsonFlag {
+			fmt.Printf("Warning: stack %s does not have a total order\n", errNoTotalOrder.StackName)
 			fmt.Println("Branches are displayed in reverse lexicographic order.")
 			fmt.Println()
-
-			branches = stack.Branches()
-			totalOrder = false
-		} else if err != nil {
-			return err
 		}
-		ctx := context.Background()
-		prsBySrcBranch := map[string]githost.PullRequest{}
-		if branchPRsFlag {
-			var actionErr error
-			action := func() {
-				prs, err := concurrent.Map(ctx, branches, func(ctx context.Context, branch string) (githost.PullRequest, error) {
-					pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
-					if errors.Is(err, githost.ErrDoesNotExist) {
-						return githost.PullRequest{}, nil
-					} else if err != nil {
-						return githost.PullRequest{}, err
-					}
-					return pr, nil
-				})
-				if err != nil {
-					actionErr = err
-					return
-				}
-				for _, pr := range prs {
-					if pr.SourceBranch == "" {
-						continue
-					}
-					prsBySrcBranch[pr.SourceBranch] = pr
-				}
-			}
 
-			vocab := host.GetVocabulary()
-			err := spinner.New().Title(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural)).Action(action).Run()
+		branches = tree.Branches()
+		totalOrder = false
+	} else if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	prsBySrcBranch := map[string]githost.PullRequest{}
+	if branchPRsFlag {
+		var actionErr error
+		action := func() {
+			prs, err := concurrent.Map(ctx, branches, func(ctx context.Context, branch string) (githost.PullRequest, error) {
+				pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+				if errors.Is(err, githost.ErrDoesNotExist) {
+					return githost.PullRequest{}, nil
+				} else if err != nil {
+					return githost.PullRequest{}, err
+				}
+				return pr, nil
+			})
 			if err != nil {
-				return err
+				actionErr = err
+				return
 			}
-			if actionErr != nil {
-				return actionErr
+			for _, pr := range prs {
+				if pr.SourceBranch == "" {
+					continue
+				}
+				prsBySrcBranch[pr.SourceBranch] = pr
 			}
-			benchmarkPoint("listCmd", "fetched pull requests")
 		}
 
-		// TODO: if no heremarker, e.g. if I'm on another branch,
-		// render all branch names without indentation. Looks less ugly
+		vocab := host.GetVocabulary()
+		err := runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural), action)
+		if err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+		benchmarkPoint("listCmd", "fetched pull requests")
+	}
+
+	if jsonFlag {
+		return printJSON(jsonOutput{
+			Stacks:         newJSONStacks(tree.Stacks, currCommit, currBranch, prsBySrcBranch),
+			Problems:       newJSONProblems(tree.Stacks),
+			MergedBranches: newJSONMergedBranches(mergedInto, squashMergedBranches),
+		})
+	}
+
+	// TODO: if no heremarker, e.g. if I'm on another branch,
+	// render all branch names without indentation. Looks less ugly
+	if parents != nil && totalOrder {
+		ui.PrintBranchTree(
+			parents,
+			currBranch,
+			theme,
+			prsBySrcBranch,
+			branchPRsFlag,
+			host.GetVocabulary(),
+		)
+	} else {
 		ui.PrintBranchesInStack(
 			branches,
 			totalOrder,
@@ -162,8 +199,8 @@ var branchCmd = &cobra.Command{
 			branchPRsFlag,
 			host.GetVocabulary(),
 		)
-		benchmarkPoint("listCmd", "done printing branches")
+	}
+	benchmarkPoint("listCmd", "done printing branches")
 
-		return nil
-	},
+	return nil
 }
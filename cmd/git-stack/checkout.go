@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/spf13/cobra"
+)
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout <number|url>",
+	Short: "Check out a stack from a pull request or merge request",
+	Long: "Fetches the source branch of the given PR/MR, and of each PR/MR it targets down to the base of the stack, " +
+		"creates or fast-forwards a local branch for each of them, then checks out the given PR/MR's branch.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, host, defaultBranch, theme := deps.git, deps.host, deps.repoCfg.DefaultBranch, deps.theme
+		vocab := host.GetVocabulary()
+
+		repoPath, id, err := githost.ParseChangeRequestRef(args[0])
+		if err != nil {
+			return err
+		}
+		// Self-hosted instances may serve repos under a path prefix.
+		if repoPath != "" && !strings.EqualFold(repoPath, deps.remote.URLPath) &&
+			!strings.HasSuffix(strings.ToLower(repoPath), "/"+strings.ToLower(deps.remote.URLPath)) {
+			return fmt.Errorf("%s belongs to %s, not to the %s remote (%s)",
+				args[0], repoPath, deps.remote.Name, deps.remote.URLPath)
+		}
+
+		// From the top of the stack to the bottom.
+		var prs []githost.PullRequest
+		var base string
+		var actionErr error
+		action := func() {
+			pr, err := host.GetChangeRequestByID(deps.remote.URLPath, id)
+			if errors.Is(err, githost.ErrDoesNotExist) {
+				actionErr = fmt.Errorf("no %s found with number %d", vocab.ChangeRequestName, id)
+				return
+			} else if err != nil {
+				actionErr = err
+				return
+			}
+			prs = append(prs, pr)
+			for {
+				base = prs[len(prs)-1].TargetBranch
+				if base == defaultBranch || slices.ContainsFunc(prs, func(p githost.PullRequest) bool {
+					return p.SourceBranch == base
+				}) {
+					return
+				}
+				pr, err := host.GetChangeReqeuest(deps.remote.URLPath, base)
+				if errors.Is(err, githost.ErrDoesNotExist) {
+					return
+				} else if err != nil {
+					actionErr = err
+					return
+				}
+				prs = append(prs, pr)
+			}
+		}
+		err = runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural), action)
+		if err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+		if prs[0].State != githost.PullRequestStateOpen {
+			return fmt.Errorf("%s %d is %s", vocab.ChangeRequestName, id, strings.ToLower(string(prs[0].State)))
+		}
+		// The source branches of change requests from forks aren't on the remote.
+		for _, pr := range prs {
+			if pr.FromFork {
+				return fmt.Errorf("%s %d is from a fork, %s from forks are not supported",
+					vocab.ChangeRequestName, pr.ID, vocab.ChangeRequestNamePlural)
+			}
+		}
+
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		var branches []string
+		for _, pr := range prs {
+			branches = append(branches, pr.SourceBranch)
+		}
+		toFetch := branches
+		if _, ok := hashes[base]; !ok && base != defaultBranch {
+			toFetch = append(slices.Clone(branches), base)
+		}
+		if err := git.FetchBranches(deps.remote.Name, toFetch); err != nil {
+			return err
+		}
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+
+		// From the bottom of the stack, so that each branch is listed in the order it's stacked.
+		var results []string
+		for i := len(toFetch) - 1; i >= 0; i-- {
+			b := toFetch[i]
+			upstream := libgit.Upstream{Remote: deps.remote.Name, BranchName: b}
+			if _, ok := hashes[b]; !ok {
+				if err := git.CreateTrackingBranch(b, upstream); err != nil {
+					return err
+				}
+				results = append(results, fmt.Sprintf("%s (%s)", b, theme.SecondaryColor.Render("created")))
+				continue
+			}
+			remoteBranch := upstream.Remote + "/" + upstream.BranchName
+			mergeBase, err := git.GetMergeBase(b, remoteBranch)
+			if err != nil {
+				return err
+			}
+			if mergeBase != hashes[b] {
+				// Not a fast-forward, the local branch has commits that aren't on the remote.
+				results = append(results, fmt.Sprintf("%s (%s)", b, theme.QuaternaryColor.Render("has local changes, not updated")))
+				continue
+			}
+			if err := git.FastForward(b, remoteBranch); err != nil {
+				return err
+			}
+			results = append(results, fmt.Sprintf("%s (%s)", b, theme.TertiaryColor.Render("updated")))
+		}
+		if base != defaultBranch {
+			if err := git.SetStackBase(branches[len(branches)-1], base); err != nil {
+				return err
+			}
+		}
+		if err := git.Checkout(prs[0].SourceBranch); err != nil {
+			return err
+		}
+
+		fmt.Printf("Checked out %s from %s #%d: %s\n", prs[0].SourceBranch, vocab.ChangeRequestName, id, prs[0].Title)
+		fmt.Println(strings.Repeat(" ", 2) + `(use "git stack branch" to see the branches in the stack)`)
+		for _, r := range results {
+			fmt.Println(strings.Repeat(" ", 8) + r)
+		}
+		return nil
+	},
+}
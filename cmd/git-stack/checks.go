@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/spf13/cobra"
+)
+
+var checksWatchFlag bool
+var checksIntervalFlag time.Duration
+
+func init() {
+	checksCmd.Flags().BoolVarP(&checksWatchFlag, "watch", "w", false, "Wait until the checks on every branch have finished")
+	checksCmd.Flags().DurationVar(&checksIntervalFlag, "interval", 15*time.Second, "How often to refresh the checks with --watch")
+}
+
+var checksCmd = &cobra.Command{
+	Use:   "checks",
+	Short: "Show the CI status of each branch in the current stack",
+	Long: "Shows the CI status of each branch's change request, with links to the failed jobs. " +
+		"With --watch, refreshes until the checks on every branch have finished. " +
+		"Exits with an error if any checks failed.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host, theme := deps.git, deps.repoCfg.DefaultBranch, deps.host, deps.theme
+		vocab := host.GetVocabulary()
+
+		_, branches, err := getCurrentTree(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+
+		fetch := func() ([]branchChecks, error) {
+			return concurrent.Map(context.Background(), branches, func(ctx context.Context, branch string) (branchChecks, error) {
+				pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+				if errors.Is(err, githost.ErrDoesNotExist) {
+					return branchChecks{branch: branch}, nil
+				} else if err != nil {
+					return branchChecks{}, err
+				}
+				checks, err := host.GetChecks(deps.remote.URLPath, pr)
+				return branchChecks{branch: branch, pr: pr, checks: checks}, err
+			})
+		}
+
+		var results []branchChecks
+		var actionErr error
+		action := func() {
+			results, actionErr = fetch()
+		}
+		if err := runWithSpinner("Fetching checks...", action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		// Redraw the table in place while watching.
+		var printedLines int
+		for {
+			finished := true
+			for _, r := range results {
+				if r.status() == githost.CIStatusPending {
+					finished = false
+				}
+			}
+			out := formatChecks(results, vocab, theme)
+			if checksWatchFlag && !finished {
+				out += fmt.Sprintf("\nWaiting for checks to finish, last updated at %s\n", time.Now().Format(time.TimeOnly))
+			}
+			if printedLines > 0 {
+				fmt.Printf("\033[%dA\033[J", printedLines)
+			}
+			fmt.Print(out)
+			printedLines = strings.Count(out, "\n")
+
+			if !checksWatchFlag || finished {
+				break
+			}
+			time.Sleep(checksIntervalFlag)
+			results, err = fetch()
+			if err != nil {
+				return err
+			}
+		}
+
+		var failed []string
+		for _, r := range results {
+			if r.status() == githost.CIStatusFailure {
+				failed = append(failed, r.branch)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("checks failed on: %s", strings.Join(failed, ", "))
+		}
+		return nil
+	},
+}
+
+type branchChecks struct {
+	branch string
+	// Zero if the branch has no open change request.
+	pr     githost.PullRequest
+	checks []githost.Check
+}
+
+func (b branchChecks) status() githost.CIStatus {
+	var statuses []githost.CIStatus
+	for _, c := range b.checks {
+		statuses = append(statuses, c.Status)
+	}
+	return githost.CombineCIStatuses(statuses)
+}
+
+// formatChecks returns a table of the CI status of each branch, listing the failed checks below each branch.
+func formatChecks(results []branchChecks, vocab githost.Vocabulary, theme config.Theme) string {
+	var width int
+	for _, r := range results {
+		width = max(width, len(r.branch))
+	}
+
+	var sb strings.Builder
+	for _, r := range results {
+		name := fmt.Sprintf("%-*s", width, r.branch)
+		if r.pr.ID == 0 {
+			fmt.Fprintf(&sb, "%s  No %s\n", name, vocab.ChangeRequestName)
+			continue
+		}
+
+		var done, failed int
+		for _, c := range r.checks {
+			if c.Status != githost.CIStatusPending {
+				done++
+			}
+			if c.Status == githost.CIStatusFailure {
+				failed++
+			}
+		}
+		var summary string
+		switch r.status() {
+		case githost.CIStatusSuccess:
+			summary = theme.SecondaryColor.Render("passed")
+		case githost.CIStatusFailure:
+			summary = theme.QuaternaryColor.Render(fmt.Sprintf("failed (%d of %d checks)", failed, len(r.checks)))
+		case githost.CIStatusPending:
+			summary = theme.TertiaryColor.Render(fmt.Sprintf("running (%d of %d checks done)", done, len(r.checks)))
+		default:
+			summary = "no checks"
+		}
+		fmt.Fprintf(&sb, "%s  %s\n", name, summary)
+
+		for _, c := range r.checks {
+			if c.Status == githost.CIStatusFailure {
+				fmt.Fprintf(&sb, "%s%s %s\n", strings.Repeat(" ", 8), theme.QuaternaryColor.Render(c.Name), c.WebURL)
+			}
+		}
+	}
+	return sb.String()
+}
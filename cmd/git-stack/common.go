@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/raymondji/git-stack-cli/concurrent"
 	"github.com/raymondji/git-stack-cli/config"
 	"github.com/raymondji/git-stack-cli/githost"
 	"github.com/raymondji/git-stack-cli/libgit"
 	"github.com/raymondji/git-stack-cli/slices"
+	"github.com/raymondji/git-stack-cli/snapshot"
 	"github.com/raymondji/git-stack-cli/stackparser"
 	"golang.org/x/exp/maps"
 )
@@ -18,14 +26,17 @@ type deps struct {
 	host    githost.Host
 	repoCfg config.RepoConfig
 	theme   config.Theme
-	remote  libgit.Remote
+	// The remote that change requests are opened against.
+	remote libgit.Remote
+	// The remote that branches are pushed to, may be the same as remote.
+	pushRemote libgit.Remote
 }
 
 func initDeps() (deps, error) {
 	git := libgit.New()
 	benchmarkPoint("initDeps", "done initiating git")
 
-	remote, err := git.GetRemote()
+	origin, err := git.GetRemote("origin")
 	if err != nil {
 		return deps{}, err
 	}
@@ -37,30 +48,330 @@ func initDeps() (deps, error) {
 	}
 	benchmarkPoint("initDeps", "loaded config")
 
-	repoCfg, ok := cfg.Repositories[remote.URLPath]
+	repoCfg, ok := cfg.Repositories[origin.URLPath]
 	if !ok {
 		return deps{}, fmt.Errorf(
 			"no config found for the current repo (%s)"+
 				", please setup git stack using the `git stack init` command",
-			remote.URLPath)
+			origin.URLPath)
 	}
 
-	host, err := githost.New(remote.Kind, repoCfg)
+	remote, pushRemote := origin, origin
+	if repoCfg.TargetRemote != "" && repoCfg.TargetRemote != origin.Name {
+		remote, err = git.GetRemote(repoCfg.TargetRemote)
+		if err != nil {
+			return deps{}, err
+		}
+	}
+	if repoCfg.PushRemote != "" && repoCfg.PushRemote != origin.Name {
+		pushRemote, err = git.GetRemote(repoCfg.PushRemote)
+		if err != nil {
+			return deps{}, err
+		}
+	}
+	var sourceRepoPath string
+	if pushRemote.URLPath != remote.URLPath {
+		sourceRepoPath = pushRemote.URLPath
+	}
+
+	host, err := githost.New(remote.Kind, repoCfg, sourceRepoPath)
 	if err != nil {
 		return deps{}, err
 	}
 
 	out := deps{
-		theme:   config.NewTheme(cfg.Theme),
-		git:     git,
-		host:    host,
-		repoCfg: repoCfg,
-		remote:  remote,
+		theme:      config.NewTheme(cfg.Theme),
+		git:        git,
+		host:       host,
+		repoCfg:    repoCfg,
+		remote:     remote,
+		pushRemote: pushRemote,
 	}
 	benchmarkPoint("initDeps", "done")
 	return out, nil
 }
 
+// parseStacks parses all stacks from the git log, excluding any branches that were squash
+// merged into their stack's base. Returns (stacks, squash merged branches, error).
+func parseStacks(git libgit.Git, defaultBranch string) ([]stackparser.Stack, []string, error) {
+	bases, err := git.GetStackBases()
+	if err != nil {
+		return nil, nil, err
+	}
+	log, err := git.LogAll(getBaseBranches(bases, defaultBranch)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	stacks, err := stackparser.ParseStacks(log, stackparser.WithBases(defaultBranch, bases))
+	if err != nil {
+		return nil, nil, err
+	}
+	squashMerged, err := getSquashMergedBranches(git, stacks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(squashMerged) == 0 {
+		return stacks, nil, nil
+	}
+
+	// The squash merged branches may be the only ones with a base configured,
+	// so carry the base over to the rest of the stack.
+	stackBases := map[string]string{}
+	for _, s := range stacks {
+		for _, b := range s.Branches() {
+			stackBases[b] = s.Base
+		}
+	}
+	stacks, err = stackparser.ParseStacks(
+		log,
+		stackparser.WithExcludedBranches(squashMerged...),
+		stackparser.WithBases(defaultBranch, stackBases),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stacks, squashMerged, nil
+}
+
+// snapshotBranches records the tips of all local branches before the current command
+// modifies them, so that it can be reverted with git stack undo.
+func snapshotBranches(git libgit.Git) error {
+	_, err := snapshot.Take(git, commandLine())
+	return err
+}
+
+// commandLine returns the command being run, e.g. "git stack rebase main".
+func commandLine() string {
+	return strings.Join(append([]string{"git stack"}, os.Args[1:]...), " ")
+}
+
+// getStacksWithBranch returns the stacks that contain branch. There may be more than one
+// if stacks share branches.
+func getStacksWithBranch(stacks []stackparser.Stack, branch string) []stackparser.Stack {
+	var out []stackparser.Stack
+	for _, s := range stacks {
+		for _, b := range s.Branches() {
+			if b == branch {
+				out = append(out, s)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// getCurrentTree returns the stack tree containing HEAD and its branches, ordered from the top of the
+// stack if the stack is linear and totally ordered.
+func getCurrentTree(git libgit.Git, defaultBranch string) (stackparser.Tree, []string, error) {
+	stacks, _, err := parseStacks(git, defaultBranch)
+	if err != nil {
+		return stackparser.Tree{}, nil, err
+	}
+	currCommit, err := git.GetShortCommitHash("HEAD")
+	if err != nil {
+		return stackparser.Tree{}, nil, err
+	}
+	tree, err := stackparser.GetCurrentTree(stacks, currCommit)
+	if err != nil {
+		return stackparser.Tree{}, nil, err
+	}
+	branches := tree.Branches()
+	if tree.IsLinear() {
+		if ordered, err := tree.Stacks[0].TotalOrderedBranches(); err == nil {
+			branches = ordered
+		}
+	}
+	return tree, branches, nil
+}
+
+// getBaseBranches returns the default branch and all other branches that stacks are based on.
+func getBaseBranches(bases map[string]string, defaultBranch string) []string {
+	seen := map[string]struct{}{defaultBranch: {}}
+	out := []string{defaultBranch}
+	for _, base := range bases {
+		if _, ok := seen[base]; ok {
+			continue
+		}
+		seen[base] = struct{}{}
+		out = append(out, base)
+	}
+	return out
+}
+
+// getSquashMergedBranches returns branches that landed in their stack's base through a
+// squash merge or rebase merge. Within each stack, branches are returned from the bottom up.
+func getSquashMergedBranches(git libgit.Git, stacks []stackparser.Stack) ([]string, error) {
+	hashes, err := git.GetBranchHashes()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := loadSquashMergedCache(git)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	// Only the entries used this time are saved, so that stale ones don't pile up.
+	used := map[string]bool{}
+	isSquashMerged := func(branch string, base string, ref string) (bool, error) {
+		if _, ok := hashes[ref]; !ok {
+			// ref isn't a local branch, so there's no stable hash to cache the result by.
+			return git.IsSquashMerged(branch, base, ref)
+		}
+		key := strings.Join([]string{hashes[branch], hashes[base], hashes[ref]}, " ")
+		mu.Lock()
+		merged, ok := cache[key]
+		mu.Unlock()
+		if !ok {
+			var err error
+			merged, err = git.IsSquashMerged(branch, base, ref)
+			if err != nil {
+				return false, err
+			}
+		}
+		mu.Lock()
+		used[key] = merged
+		mu.Unlock()
+		return merged, nil
+	}
+
+	mergedPerStack, err := concurrent.Map(
+		context.Background(),
+		stacks,
+		func(ctx context.Context, s stackparser.Stack) ([]string, error) {
+			branches, err := s.TotalOrderedBranches()
+			if err != nil {
+				// Without an order we can't tell which branch to compare against,
+				// so treat them all as unmerged.
+				return nil, nil
+			}
+
+			// Branches higher up in the stack can only have been merged if the branches
+			// below them were. Compare each against the one below it so that only its own
+			// changes are considered.
+			var merged []string
+			var base string
+			for i := len(branches) - 1; i >= 0; i-- {
+				ok, err := isSquashMerged(branches[i], base, s.Base)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					break
+				}
+				merged = append(merged, branches[i])
+				base = branches[i]
+			}
+			return merged, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !maps.Equal(cache, used) {
+		if err := saveSquashMergedCache(git, used); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []string
+	seen := map[string]struct{}{}
+	for _, merged := range mergedPerStack {
+		for _, b := range merged {
+			if _, ok := seen[b]; ok {
+				continue
+			}
+			seen[b] = struct{}{}
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// The results of git.IsSquashMerged only depend on the commits being compared, so they're
+// cached in the git dir to avoid diffing every branch on every command. Each line is
+// "<branch hash> <base hash> <ref hash>\t<true|false>", where the base hash may be empty.
+func squashMergedCachePath(git libgit.Git) (string, error) {
+	dir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-stack", "squash-merged"), nil
+}
+
+func loadSquashMergedCache(git libgit.Git) (map[string]bool, error) {
+	path, err := squashMergedCachePath(git)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]bool{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read squash merge cache, err: %v", err)
+	}
+	cache := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, merged, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		cache[key] = merged == "true"
+	}
+	return cache, nil
+}
+
+func saveSquashMergedCache(git libgit.Git, cache map[string]bool) error {
+	path, err := squashMergedCachePath(git)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, key := range sortedKeys(cache) {
+		fmt.Fprintf(&sb, "%s\t%t\n", key, cache[key])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s, err: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write squash merge cache, err: %v", err)
+	}
+	return nil
+}
+
+// stackProblems describes why git stack can't fully operate on some stacks.
+type stackProblems struct {
+	divergent    []divergentStack
+	noTotalOrder []stackparser.NoTotalOrderError
+}
+
+type divergentStack struct {
+	name string
+	// Sorted lexicographically
+	divergesFrom []string
+}
+
+func getProblems(stacks []stackparser.Stack) stackProblems {
+	var problems stackProblems
+	for _, stack := range stacks {
+		got := stack.DivergesFrom()
+		if len(got) > 0 {
+			divergesFrom := maps.Keys(got)
+			sort.Strings(divergesFrom)
+			problems.divergent = append(problems.divergent, divergentStack{
+				name:         stack.Name,
+				divergesFrom: divergesFrom,
+			})
+		}
+	}
+	for _, stack := range stacks {
+		var errNoTotalOrder stackparser.NoTotalOrderError
+		if _, err := stack.TotalOrderedBranches(); errors.As(err, &errNoTotalOrder) {
+			problems.noTotalOrder = append(problems.noTotalOrder, errNoTotalOrder)
+		}
+	}
+	return problems
+}
+
 // TODO: try to format this similar to git status.
 /*
 Unmerged paths:
@@ -69,58 +380,251 @@ Unmerged paths:
         both added:      src/module2.py
 */
 func printProblems(stacks []stackparser.Stack, theme config.Theme) {
-	var divergentStacksMsgs []string
-	for _, stack := range stacks {
-		got := stack.DivergesFrom()
-		if len(got) > 0 {
-			divergentStacksMsgs = append(divergentStacksMsgs, fmt.Sprintf(
-				"%s has diverged from %s",
-				stack.Name,
-				strings.Join(maps.Keys(got), ", "),
-			))
-		}
-	}
-	if len(divergentStacksMsgs) > 0 {
+	problems := getProblems(stacks)
+	if len(problems.divergent) > 0 {
 		fmt.Println()
 		fmt.Println("Divergent stacks:")
 		fmt.Println(strings.Repeat(" ", 2) + `(use "git merge" to merge one branch into another)`)
 		fmt.Println(strings.Repeat(" ", 2) + `(use "git stack rebase" to rebase one stack onto another)`)
-		for _, msg := range divergentStacksMsgs {
+		for _, d := range problems.divergent {
+			msg := fmt.Sprintf("%s has diverged from %s", d.name, strings.Join(d.divergesFrom, ", "))
 			fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(msg))
 		}
 	}
 
-	var noTotalOrderMsgs []string
-	for _, stack := range stacks {
-		_, err := stack.TotalOrderedBranches()
-		if err != nil {
-			noTotalOrderMsgs = append(noTotalOrderMsgs, err.Error())
-		}
-	}
-	if len(noTotalOrderMsgs) > 0 {
+	if len(problems.noTotalOrder) > 0 {
 		fmt.Println()
 		fmt.Println("Partially ordered stacks:")
 		fmt.Println(strings.Repeat(" ", 2) + `(use "git reset --hard <ref>..." to undo a merge commit)`)
-		fmt.Println(strings.Repeat(" ", 2) + `(use "git log --oneline --graph" to visualize the commit history)`)
-		for _, msg := range noTotalOrderMsgs {
-			fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(msg))
+		fmt.Println(strings.Repeat(" ", 2) + `(use "git stack graph" to see how the stacks are connected)`)
+		for _, err := range problems.noTotalOrder {
+			fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(err.Error()))
 		}
 	}
 }
 
-func printMergedBranches(branches []string, defaultBranch string, theme config.Theme) {
-	branches = slices.Filter(branches, func(b string) bool {
-		return b != defaultBranch
+// handleRebaseErr explains how to resolve the in-progress rebase if err stopped it partway,
+// e.g. due to a conflict. Otherwise it returns err unchanged. followUp lists the steps of
+// the current command that are left once the rebase finishes, as hints such as
+// `use "git stack push" to ...`, for git stack continue to print.
+func handleRebaseErr(git libgit.Git, theme config.Theme, err error, followUp ...string) error {
+	if err == nil {
+		return nil
+	}
+	state, ok, stateErr := git.GetRebaseState()
+	if stateErr != nil || !ok {
+		return err
+	}
+	if len(followUp) > 0 {
+		if err := saveFollowUp(git, followUp); err != nil {
+			return err
+		}
+	}
+	if printErr := printRebaseState(git, state, theme); printErr != nil {
+		return printErr
+	}
+	return errors.New("rebase stopped, resolve the conflicts to continue")
+}
+
+func printRebaseState(git libgit.Git, state libgit.RebaseState, theme config.Theme) error {
+	conflicts, err := git.GetConflictedFiles()
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Rebase stopped while replaying %s", state.CurrentBranch())
+	if state.StoppedAt != "" {
+		msg += fmt.Sprintf(" at %s", shortHash(state.StoppedAt))
+	}
+	fmt.Println(msg)
+	fmt.Println(strings.Repeat(" ", 2) + `(fix conflicts and then run "git stack continue")`)
+	fmt.Println(strings.Repeat(" ", 2) + `(use "git stack abort" to return the stack to before the rebase)`)
+	if len(conflicts) > 0 {
+		fmt.Println()
+		fmt.Println("Unmerged paths:")
+		fmt.Println(strings.Repeat(" ", 2) + `(use "git add <file>..." to mark resolution)`)
+		for _, f := range conflicts {
+			fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(f))
+		}
+	}
+	if len(state.UpdatedBranches) > 0 {
+		fmt.Println()
+		fmt.Println("Replayed branches (updated when the rebase finishes):")
+		for _, b := range state.UpdatedBranches {
+			fmt.Println(strings.Repeat(" ", 8) + b)
+		}
+	}
+	var remaining []string
+	if len(state.PendingBranches) > 1 {
+		remaining = append(remaining, state.PendingBranches[1:]...)
+	}
+	if state.Branch != "" && state.CurrentBranch() != state.Branch {
+		remaining = append(remaining, state.Branch)
+	}
+	if len(remaining) > 0 {
+		fmt.Println()
+		fmt.Println("Remaining branches:")
+		for _, b := range remaining {
+			fmt.Println(strings.Repeat(" ", 8) + b)
+		}
+	}
+	followUp, err := loadFollowUp(git)
+	if err != nil {
+		return err
+	}
+	if len(followUp) > 0 {
+		fmt.Println()
+		fmt.Println("Left to do once the rebase finishes:")
+		printFollowUp(followUp)
+	}
+	return nil
+}
+
+// The follow-up steps of a command whose rebase stopped are kept in the git dir until
+// git stack continue or git stack abort, one hint per line.
+func followUpPath(git libgit.Git) (string, error) {
+	dir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-stack", "continue"), nil
+}
+
+func saveFollowUp(git libgit.Git, followUp []string) error {
+	path, err := followUpPath(git)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s, err: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(followUp, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to save follow-up steps, err: %v", err)
+	}
+	return nil
+}
+
+func loadFollowUp(git libgit.Git) ([]string, error) {
+	path, err := followUpPath(git)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read follow-up steps, err: %v", err)
+	}
+	return slices.Filter(strings.Split(string(data), "\n"), func(line string) bool {
+		return line != ""
+	}), nil
+}
+
+func clearFollowUp(git libgit.Git) error {
+	path, err := followUpPath(git)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear follow-up steps, err: %v", err)
+	}
+	return nil
+}
+
+func printFollowUp(followUp []string) {
+	for _, hint := range followUp {
+		fmt.Println(strings.Repeat(" ", 2) + "(" + hint + ")")
+	}
+}
+
+// getMergedBranches returns the local branches merged into any base branch, mapped to the
+// base branch they're merged into. Base branches themselves are excluded.
+func getMergedBranches(git libgit.Git, defaultBranch string) (map[string]string, error) {
+	bases, err := git.GetStackBases()
+	if err != nil {
+		return nil, err
+	}
+	baseBranches := getBaseBranches(bases, defaultBranch)
+	// The default branch goes first, so that branches merged into it as well as another
+	// base are reported as merged into the default branch.
+	sort.Strings(baseBranches[1:])
+	mergedBranches, err := concurrent.Map(context.Background(), baseBranches, func(ctx context.Context, base string) ([]string, error) {
+		return git.GetMergedBranches(base)
 	})
-	if len(branches) > 0 {
+	if err != nil {
+		return nil, err
+	}
+
+	mergedInto := map[string]string{}
+	for i, base := range baseBranches {
+		for _, b := range mergedBranches[i] {
+			if _, ok := mergedInto[b]; ok || slices.Contains(baseBranches, b) {
+				continue
+			}
+			mergedInto[b] = base
+		}
+	}
+	return mergedInto, nil
+}
+
+// printMergedBranches prints the branches that are excluded from stacks. Keys of mergedInto are branch
+// names, values are the base branch they're merged into (see getMergedBranches).
+func printMergedBranches(mergedInto map[string]string, squashMergedBranches []string, theme config.Theme) {
+	if len(mergedInto) > 0 || len(squashMergedBranches) > 0 {
 		fmt.Println()
-		fmt.Printf("Excluding branches merged into %s:\n", defaultBranch)
+		fmt.Println("Excluding merged branches:")
 		fmt.Println(strings.Repeat(" ", 2) + `(use "git commit ..." to add a commit on a branch for it to appear as a stack)`)
 		fmt.Println(strings.Repeat(" ", 2) + `(use "git branch -D <branch>" to remove unneeded branches)`)
 	}
-	for _, b := range branches {
-		fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(b))
+	for _, b := range sortedKeys(mergedInto) {
+		fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(b) + fmt.Sprintf(" (merged into %s)", mergedInto[b]))
+	}
+	for _, b := range squashMergedBranches {
+		fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(b) + " (squash merged)")
+	}
+}
+
+// mergedError returns why the current branch can't be shown as a stack, or an empty string if
+// it isn't merged.
+func mergedError(currBranch string, defaultBranch string, mergedInto map[string]string, squashMergedBranches []string) string {
+	if currBranch == defaultBranch {
+		return fmt.Sprintf("the current branch is not a valid stack (it's merged into %s)", defaultBranch)
+	}
+	if base, ok := mergedInto[currBranch]; ok {
+		return fmt.Sprintf("the current branch is not a valid stack (it's merged into %s)", base)
+	}
+	if slices.Contains(squashMergedBranches, currBranch) {
+		return "the current branch is not a valid stack (it's squash merged)"
+	}
+	return ""
+}
+
+// getWantTargets returns the target branch each branch's change request should have.
+// Branches are expected to be ordered from the top of the stack to the bottom.
+func getWantTargets(branches []string, base string) map[string]string {
+	wantTargets := map[string]string{}
+	for i, b := range branches {
+		if i == len(branches)-1 {
+			wantTargets[b] = base
+		} else {
+			wantTargets[b] = branches[i+1]
+		}
+	}
+	return wantTargets
+}
+
+// getTreeWantTargets is like getWantTargets, for branches stacked as a tree.
+// Keys of parents are branch names, values are the branch they're stacked on (see stackparser.Tree).
+func getTreeWantTargets(parents map[string]string, base string) map[string]string {
+	wantTargets := map[string]string{}
+	for b, p := range parents {
+		if p == "" {
+			wantTargets[b] = base
+		} else {
+			wantTargets[b] = p
+		}
 	}
+	return wantTargets
 }
 
 var (
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var continueCmd = &cobra.Command{
+	Use:   "continue",
+	Short: "Continue a rebase that stopped on a conflict",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git := deps.git
+
+		followUp, err := loadFollowUp(git)
+		if err != nil {
+			return err
+		}
+		if _, ok, err := git.GetRebaseState(); err != nil {
+			return err
+		} else if ok {
+			if err := handleRebaseErr(git, deps.theme, git.RebaseContinue()); err != nil {
+				return err
+			}
+		} else if len(followUp) == 0 {
+			return fmt.Errorf("no rebase in progress")
+		}
+
+		// The rest of the command that started the rebase isn't resumed automatically.
+		if len(followUp) > 0 {
+			fmt.Println("Rebase finished, the command that started it has steps left:")
+			printFollowUp(followUp)
+			if err := clearFollowUp(git); err != nil {
+				return err
+			}
+		}
+		fmt.Println()
+		return printBranches(deps, "")
+	},
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var createMessageFlag string
+var createAllFlag bool
+
+func init() {
+	createCmd.Flags().StringVarP(&createMessageFlag, "message", "m", "", "Commit message, opens an editor if not set")
+	createCmd.Flags().BoolVarP(&createAllFlag, "all", "a", false, "Commit all changes to tracked files, see git commit -a")
+}
+
+var createCmd = &cobra.Command{
+	Use:     "create <branch>",
+	Aliases: []string{"c"},
+	Short:   "Create a new branch on top of the current one and commit staged changes",
+	Long: "If the current branch is in the middle of a stack, the new branch is inserted above it " +
+		"and the branches above are rebased onto the new branch, so the stack stays in order.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
+		name := args[0]
+
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		if _, ok := hashes[name]; ok {
+			return fmt.Errorf("a branch named %s already exists", name)
+		}
+		if ok, err := git.HasChangesToCommit(createAllFlag); err != nil {
+			return err
+		} else if !ok {
+			hint := "stage them with git add"
+			if !createAllFlag {
+				hint += " or use --all"
+			}
+			return fmt.Errorf("no changes to commit, %s", hint)
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		if currBranch == "HEAD" {
+			return fmt.Errorf("HEAD is detached, check out a branch first")
+		}
+
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		// The top of the stack above the current branch, if any.
+		var top string
+		currStacks := getStacksWithBranch(stacks, currBranch)
+		if len(currStacks) > 0 {
+			s := currStacks[0]
+			branches, err := s.TotalOrderedBranches()
+			var errNoTotalOrder stackparser.NoTotalOrderError
+			if errors.As(err, &errNoTotalOrder) {
+				return fmt.Errorf("cannot insert a branch into stack %s, it is partially ordered", s.Name)
+			} else if err != nil {
+				return err
+			}
+			if branches[0] != currBranch {
+				if len(currStacks) > 1 {
+					var names []string
+					for _, s := range currStacks {
+						names = append(names, s.Name)
+					}
+					return fmt.Errorf("cannot insert a branch above %s, it is shared by multiple stacks (%s)",
+						currBranch, strings.Join(names, ", "))
+				}
+				if len(s.DivergesFrom()) > 0 {
+					return fmt.Errorf("cannot insert a branch into stack %s, it has diverged", s.Name)
+				}
+				top = branches[0]
+			}
+		}
+
+		if top != "" {
+			if err := snapshotBranches(git); err != nil {
+				return err
+			}
+		}
+		if err := git.CreateBranch(name, currBranch); err != nil {
+			return err
+		}
+		if err := git.Checkout(name); err != nil {
+			return err
+		}
+		err = git.Commit(libgit.CommitOpts{
+			Message: createMessageFlag,
+			All:     createAllFlag,
+		})
+		if err != nil {
+			// Leave the repo as it was, the changes are still in the working tree.
+			if err := git.Checkout(currBranch); err != nil {
+				return err
+			}
+			if err := git.DeleteBranchIfExists(name); err != nil {
+				return err
+			}
+			return err
+		}
+		fmt.Printf("Created branch %s on %s\n", name, currBranch)
+
+		if top != "" {
+			_, err := git.Rebase(currBranch, libgit.RebaseOpts{
+				Onto:       name,
+				Branch:     top,
+				UpdateRefs: true,
+			})
+			if err != nil {
+				return handleRebaseErr(git, deps.theme, err,
+					fmt.Sprintf(`use "git checkout %s" to return to the new branch`, name),
+					`use "git stack push" to update the target branches of their change requests`)
+			}
+			if err := git.Checkout(name); err != nil {
+				return err
+			}
+			fmt.Printf("Restacked the branches above %s onto %s\n", currBranch, name)
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack push" to update the target branches of their change requests)`)
+		}
+
+		fmt.Println()
+		return printBranches(deps, "")
+	},
+}
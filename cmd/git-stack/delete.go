@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/raymondji/git-stack-cli/cleanup"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var deleteKeepRemoteFlag bool
+var deleteDryRunFlag bool
+
+func init() {
+	deleteCmd.Flags().BoolVar(&deleteKeepRemoteFlag, "keep-remote", false, "Don't delete the remote branches")
+	deleteCmd.Flags().BoolVarP(&deleteDryRunFlag, "dry-run", "n", false, "Show what would be deleted without deleting anything")
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [stack]",
+	Short: "Delete a stack's branches and close their change requests",
+	Long: "Deletes every branch of the stack, defaulting to the current stack, both locally and remotely, " +
+		"and closes their change requests. Branches shared with other stacks are kept.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
+		vocab := host.GetVocabulary()
+
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		var s stackparser.Stack
+		if len(args) == 1 {
+			idx := slices.IndexFunc(stacks, func(s stackparser.Stack) bool {
+				return s.Name == args[0]
+			})
+			if idx == -1 {
+				return fmt.Errorf("no stack named: %s", args[0])
+			}
+			s = stacks[idx]
+		} else {
+			currCommit, err := git.GetShortCommitHash("HEAD")
+			if err != nil {
+				return err
+			}
+			s, err = stackparser.GetCurrent(stacks, currCommit)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Ordered from the top of the stack where possible, to close the change requests
+		// above before the ones they target.
+		branches, err := s.TotalOrderedBranches()
+		if err != nil {
+			branches = s.Branches()
+		}
+		var shared []string
+		branches = slices.DeleteFunc(branches, func(b string) bool {
+			if len(getStacksWithBranch(stacks, b)) > 1 {
+				shared = append(shared, b)
+				return true
+			}
+			return false
+		})
+
+		var plan []cleanup.Branch
+		var actionErr error
+		action := func() {
+			plan, actionErr = cleanup.Plan(host, deps.remote.URLPath, branches...)
+		}
+		if err := runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		var summary []string
+		for _, b := range plan {
+			line := b.Name
+			if b.ChangeRequest.ID != 0 {
+				line += fmt.Sprintf(" (%s)", b.ChangeRequest.WebURL)
+			}
+			summary = append(summary, line)
+		}
+		if deleteDryRunFlag {
+			fmt.Printf("Would delete the branches of stack %s and close their %s:\n", s.Name, vocab.ChangeRequestNamePlural)
+			for _, line := range summary {
+				fmt.Println(strings.Repeat(" ", 8) + line)
+			}
+			printSharedBranches(shared)
+			return nil
+		}
+
+		confirmed := false
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Delete the branches of stack %s and close their %s?", s.Name, vocab.ChangeRequestNamePlural)).
+					Description(strings.Join(summary, "\n")).
+					Value(&confirmed),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		// Snapshot before checking out another branch, so that undo returns to the current one.
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		if slices.Contains(branches, currBranch) {
+			checkout := s.Base
+			if len(shared) > 0 {
+				checkout = shared[0]
+			}
+			if err := git.Checkout(checkout); err != nil {
+				return err
+			}
+		}
+
+		action = func() {
+			actionErr = cleanup.Run(git, host, deps.remote.URLPath, plan, cleanup.Opts{
+				PushRemote: deps.pushRemote.Name,
+				KeepRemote: deleteKeepRemoteFlag,
+			})
+		}
+		if err := runWithSpinner(fmt.Sprintf("Deleting stack %s...", s.Name), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		fmt.Printf("Deleted the branches of stack %s and closed their %s:\n", s.Name, vocab.ChangeRequestNamePlural)
+		for _, line := range summary {
+			fmt.Println(strings.Repeat(" ", 8) + line)
+		}
+		printSharedBranches(shared)
+		return nil
+	},
+}
+
+func printSharedBranches(shared []string) {
+	if len(shared) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Keeping branches shared with other stacks:")
+	for _, b := range shared {
+		fmt.Println(strings.Repeat(" ", 8) + b)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var diffStatFlag bool
+var diffNameOnlyFlag bool
+var diffAllFlag bool
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffStatFlag, "stat", false, "Show a diffstat instead of the full diff")
+	diffCmd.Flags().BoolVar(&diffNameOnlyFlag, "name-only", false, "Show only the names of changed files")
+	diffCmd.Flags().BoolVar(&diffAllFlag, "all", false, "Show the diff of every branch in the stack, from the bottom of the stack")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [branch]",
+	Short: "Show the changes introduced by a branch in the stack",
+	Long: "Diffs the branch, defaulting to the current branch, against the branch below it in the stack, " +
+		"or against the stack's base for the bottom branch. Changes made to the branch below since the " +
+		"branch was last rebased are not included.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, theme := deps.git, deps.repoCfg.DefaultBranch, deps.theme
+
+		var branch string
+		if len(args) == 1 {
+			branch = args[0]
+		} else {
+			branch, err = git.GetCurrentBranch()
+			if err != nil {
+				return err
+			}
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currStacks := getStacksWithBranch(stacks, branch)
+		if len(currStacks) == 0 {
+			return fmt.Errorf("%s is not a branch in any stack", branch)
+		}
+		tree := stackparser.GetTree(stacks, currStacks[0])
+		parents, err := tree.Parents()
+		if err != nil {
+			return err
+		}
+		getParent := func(b string) string {
+			if parents[b] == "" {
+				return tree.Base()
+			}
+			return parents[b]
+		}
+		opts := libgit.DiffOpts{
+			Stat:     diffStatFlag,
+			NameOnly: diffNameOnlyFlag,
+		}
+
+		if !diffAllFlag {
+			return git.Diff(getParent(branch), branch, opts)
+		}
+
+		// Print each branch after the branch it's stacked on.
+		var ordered []string
+		children := stackparser.Children(parents)
+		var visit func(b string)
+		visit = func(b string) {
+			ordered = append(ordered, b)
+			for _, c := range children[b] {
+				visit(c)
+			}
+		}
+		for _, root := range children[""] {
+			visit(root)
+		}
+		opts.NoPager = true
+		for i, b := range ordered {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s (compared to %s)\n", theme.PrimaryColor.Render(b), getParent(b))
+			if err := git.Diff(getParent(b), b, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <branch>",
+	Short: "Check out a branch below the current branch to edit it",
+	Long: "Checks out the branch and records the branch you came from. After committing or amending your changes, " +
+		"use git stack restack to rebase the branches above the edited branch and return to where you were.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
+		branch := args[0]
+
+		if state, ok, err := git.GetEditState(); err != nil {
+			return err
+		} else if ok {
+			return fmt.Errorf("already editing %s (use \"git stack restack\" to finish editing it first)", state.Branch)
+		}
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		if currBranch == "HEAD" {
+			return fmt.Errorf("cannot edit from a detached HEAD, check out the top of the stack first")
+		}
+		if branch == currBranch {
+			return fmt.Errorf("already on %s", branch)
+		}
+
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		s, err := stackparser.GetCurrent(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		branches, err := s.TotalOrderedBranches()
+		if err != nil {
+			return err
+		}
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		if _, ok := hashes[branch]; !ok {
+			return fmt.Errorf("no branch named: %s", branch)
+		}
+		mergeBase, err := git.GetMergeBase(branch, currBranch)
+		if err != nil {
+			return err
+		}
+		if mergeBase != hashes[branch] {
+			return fmt.Errorf("%s is not below %s in stack %s", branch, currBranch, s.Name)
+		}
+
+		if err := git.SetEditState(libgit.EditState{
+			Branch:         branch,
+			OriginalCommit: hashes[branch],
+			Top:            branches[0],
+			ReturnTo:       currBranch,
+		}); err != nil {
+			return err
+		}
+		if err := git.Checkout(branch); err != nil {
+			if unsetErr := git.UnsetEditState(); unsetErr != nil {
+				return fmt.Errorf("%v, and failed to unset the edit state, err: %v", err, unsetErr)
+			}
+			return err
+		}
+		fmt.Printf("Editing %s, commit or amend your changes\n", branch)
+		fmt.Println(strings.Repeat(" ", 2) + fmt.Sprintf(`(use "git stack restack" to rebase the branches above %s up to %s and return to %s)`, branch, branches[0], currBranch))
+		return nil
+	},
+}
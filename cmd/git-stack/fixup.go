@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/charmbracelet/huh"
 	"github.com/raymondji/git-stack-cli/libgit"
@@ -11,17 +12,21 @@ import (
 
 var fixupAddFlag bool
 var fixupRebaseFlag bool
+var fixupCommitFlag string
 
 func init() {
 	fixupCmd.Flags().BoolVarP(&fixupAddFlag, "add", "a", false, "Use git commit -a")
 	fixupCmd.Flags().BoolVarP(&fixupRebaseFlag, "rebase", "r", false, "Automatically perform a git rebase")
+	fixupCmd.Flags().StringVarP(&fixupCommitFlag, "commit", "c", "", "The commit to fixup, defaults to choosing one of the branch's commits")
 }
 
 var fixupCmd = &cobra.Command{
 	Use:     "fixup [branch]",
 	Aliases: []string{"f"},
 	Short:   "Create a commit to fixup a branch in the current stack",
-	Args:    cobra.MaximumNArgs(1),
+	Long: "Creates a fixup commit for one of the commits of the branch. If the branch has several commits, " +
+		"prompts for which one unless --commit is given.",
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		deps, err := initDeps()
 		if err != nil {
@@ -29,11 +34,7 @@ var fixupCmd = &cobra.Command{
 		}
 		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
 
-		log, err := git.LogAll(defaultBranch)
-		if err != nil {
-			return err
-		}
-		stacks, err := stackparser.ParseStacks(log)
+		stacks, _, err := parseStacks(git, defaultBranch)
 		if err != nil {
 			return err
 		}
@@ -46,15 +47,19 @@ var fixupCmd = &cobra.Command{
 			return err
 		}
 
+		// Only needed to find a branch's commits, so that --commit works in partially ordered stacks.
+		branches, orderErr := stack.TotalOrderedBranches()
+
 		var branchToFix string
 		if len(args) == 1 {
 			branchToFix = args[0]
-		} else {
-			branches, err := stack.TotalOrderedBranches()
-			if err != nil {
-				return err
+			if !slices.Contains(stack.Branches(), branchToFix) {
+				return fmt.Errorf("%s is not a branch in stack %s", branchToFix, stack.Name)
+			}
+		} else if fixupCommitFlag == "" {
+			if orderErr != nil {
+				return orderErr
 			}
-
 			var opts []huh.Option[string]
 			for _, b := range branches {
 				opts = append(opts, huh.NewOption(b, b))
@@ -74,9 +79,48 @@ var fixupCmd = &cobra.Command{
 			}
 		}
 
-		hash, err := git.GetShortCommitHash(branchToFix)
-		if err != nil {
-			return err
+		var hash string
+		if fixupCommitFlag != "" {
+			hash, err = git.GetShortCommitHash(fixupCommitFlag)
+			if err != nil {
+				return err
+			}
+			if _, ok := stack.Commits[hash]; !ok {
+				return fmt.Errorf("commit %s is not in stack %s", fixupCommitFlag, stack.Name)
+			}
+		}
+		if branchToFix != "" {
+			if orderErr != nil {
+				return orderErr
+			}
+			commits, err := getBranchCommits(git, stack, branches, branchToFix)
+			if err != nil {
+				return err
+			}
+			if hash != "" {
+				if !slices.ContainsFunc(commits, func(c libgit.Commit) bool { return c.Hash == hash }) {
+					return fmt.Errorf("commit %s is not in branch %s", fixupCommitFlag, branchToFix)
+				}
+			} else if len(commits) == 1 {
+				hash = commits[0].Hash
+			} else {
+				var opts []huh.Option[string]
+				for _, c := range commits {
+					opts = append(opts, huh.NewOption(fmt.Sprintf("%s %s", c.Hash, c.Subject), c.Hash))
+				}
+				form := huh.NewForm(
+					huh.NewGroup(
+						huh.NewSelect[string]().
+							Title(fmt.Sprintf("Choose which commit in %s to fixup", branchToFix)).
+							Options(opts...).
+							Filtering(true).
+							Value(&hash),
+					),
+				)
+				if err := form.Run(); err != nil {
+					return err
+				}
+			}
 		}
 
 		res, err := git.CommitFixup(hash, fixupAddFlag)
@@ -86,16 +130,36 @@ var fixupCmd = &cobra.Command{
 		fmt.Println(res)
 
 		if fixupRebaseFlag {
-			res, err := git.Rebase(defaultBranch, libgit.RebaseOpts{
+			if err := snapshotBranches(git); err != nil {
+				return err
+			}
+			res, err := git.Rebase(stack.Base, libgit.RebaseOpts{
 				Autosquash: true,
 				UpdateRefs: true,
 				KeepBase:   true,
 			})
 			if err != nil {
-				return err
+				return handleRebaseErr(git, deps.theme, err)
 			}
 			fmt.Println(res)
 		}
 		return nil
 	},
 }
+
+// getBranchCommits returns the commits of the branch that aren't in the branch below it,
+// ordered from newest to oldest. Branches are ordered from the top of the stack.
+func getBranchCommits(git libgit.Git, stack stackparser.Stack, branches []string, branch string) ([]libgit.Commit, error) {
+	parent := stack.Base
+	if i := slices.Index(branches, branch); i < len(branches)-1 {
+		parent = branches[i+1]
+	}
+	log, err := git.Log(parent, branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(log.Commits) == 0 {
+		return nil, fmt.Errorf("%s has no commits of its own", branch)
+	}
+	return log.Commits, nil
+}
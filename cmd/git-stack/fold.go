@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var foldCmd = &cobra.Command{
+	Use:   "fold [branch]",
+	Short: "Fold a branch into the branch below it",
+	Long: "Moves the branch below to the tip of the folded branch, then deletes the folded branch " +
+		"locally and remotely and closes its change request. Defaults to the current branch.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
+
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		branch := currBranch
+		if len(args) == 1 {
+			branch = args[0]
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currStacks := getStacksWithBranch(stacks, branch)
+		if len(currStacks) == 0 {
+			return fmt.Errorf("%s is not a branch in any stack", branch)
+		}
+		tree := stackparser.GetTree(stacks, currStacks[0])
+		if len(tree.DivergesFrom()) > 0 {
+			return fmt.Errorf("cannot fold branches in divergent stacks")
+		}
+		parents, err := tree.Parents()
+		if err != nil {
+			return err
+		}
+		parent := parents[branch]
+		if parent == "" {
+			return fmt.Errorf("%s is at the bottom of the stack, there is no branch to fold it into", branch)
+		}
+		children := stackparser.Children(parents)
+		if len(children[parent]) > 1 {
+			// The other branches would be left behind on the old tip of parent.
+			var others []string
+			for _, c := range children[parent] {
+				if c != branch {
+					others = append(others, c)
+				}
+			}
+			return fmt.Errorf("cannot fold %s into %s, other branches are stacked on %s: %s",
+				branch, parent, parent, strings.Join(others, ", "))
+		}
+		above := children[branch]
+
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		// Moving the checked out branch's ref directly would leave the index and worktree behind.
+		if currBranch == parent {
+			if err := git.ResetHard(hashes[branch]); err != nil {
+				return err
+			}
+		} else if err := git.UpdateRef("refs/heads/"+parent, hashes[branch]); err != nil {
+			return err
+		}
+		if currBranch == branch {
+			if err := git.Checkout(parent); err != nil {
+				return err
+			}
+		}
+		if err := git.DeleteBranchIfExists(branch); err != nil {
+			return err
+		}
+		fmt.Printf("Folded %s into %s\n", branch, parent)
+
+		var closed githost.PullRequest
+		var actionErr error
+		action := func() {
+			// Retarget first so that the change requests above aren't closed when the
+			// folded branch is deleted on the remote.
+			wantTargets := map[string]string{}
+			for _, b := range above {
+				wantTargets[b] = parent
+			}
+			if actionErr = retargetChangeRequests(deps, wantTargets); actionErr != nil {
+				return
+			}
+
+			pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+			if err == nil {
+				closed, actionErr = host.CloseChangeRequest(deps.remote.URLPath, pr)
+				if actionErr != nil {
+					return
+				}
+			} else if !errors.Is(err, githost.ErrDoesNotExist) {
+				actionErr = err
+				return
+			}
+			actionErr = git.DeleteRemoteBranchIfExists(deps.pushRemote.Name, branch)
+		}
+		vocab := host.GetVocabulary()
+		if err := runWithSpinner(fmt.Sprintf("Cleaning up %s...", vocab.ChangeRequestName), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+		if closed.ID != 0 {
+			fmt.Printf("Closed %s (%s)\n", vocab.ChangeRequestName, closed.WebURL)
+		}
+		fmt.Println(strings.Repeat(" ", 2) + fmt.Sprintf(`(use "git stack push" to push the folded commits to %s)`, parent))
+
+		fmt.Println()
+		return printBranches(deps, "")
+	},
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/raymondji/git-stack-cli/stackparser/commitgraph"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+var graphPRsFlag bool
+
+func init() {
+	graphCmd.Flags().BoolVar(&graphPRsFlag, "prs", false, "Whether to show PRs for each branch")
+	graphCmd.Flags().BoolVar(&graphPRsFlag, "mrs", false, "Whether to show MRs for each branch")
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show all stacks as a tree of branches",
+	Long: "Shows the branches of all stacks as a tree, starting from the branches they're based on. " +
+		"Commits without a branch are only shown where stacks fork from them.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, host, defaultBranch, theme := deps.git, deps.host, deps.repoCfg.DefaultBranch, deps.theme
+
+		var currBranch string
+		var mergedInto map[string]string
+		var squashMergedBranches []string
+		var stacks []stackparser.Stack
+		var bases map[string]string
+		err = concurrent.Run(
+			context.Background(),
+			func(ctx context.Context) error {
+				var err error
+				currBranch, err = git.GetCurrentBranch()
+				return err
+			},
+			func(ctx context.Context) error {
+				var err error
+				mergedInto, err = getMergedBranches(git, defaultBranch)
+				return err
+			},
+			func(ctx context.Context) error {
+				var err error
+				stacks, squashMergedBranches, err = parseStacks(git, defaultBranch)
+				return err
+			},
+			func(ctx context.Context) error {
+				var err error
+				bases, err = git.GetStackBases()
+				return err
+			},
+		)
+		if err != nil {
+			return err
+		}
+		log, err := git.LogAll(getBaseBranches(bases, defaultBranch)...)
+		if err != nil {
+			return err
+		}
+		dag, err := stackparser.ParseGraph(log, stackparser.WithExcludedBranches(squashMergedBranches...))
+		if err != nil {
+			return err
+		}
+		parents := commitgraph.Condense(dag)
+
+		// Merge commits are shown under their first parent, sorted by hash, and mention the others.
+		children := map[string][]string{}
+		rootsByBase := map[string][]string{}
+		for _, hash := range sortedKeys(parents) {
+			ps := parents[hash]
+			if len(ps) == 0 {
+				base := defaultBranch
+				for _, s := range stacks {
+					if _, ok := s.Commits[hash]; ok {
+						base = s.Base
+						break
+					}
+				}
+				rootsByBase[base] = append(rootsByBase[base], hash)
+				continue
+			}
+			parent := sortedKeys(ps)[0]
+			children[parent] = append(children[parent], hash)
+		}
+		byLabel := func(hashes []string) {
+			sort.Slice(hashes, func(i, j int) bool {
+				return graphNodeName(dag, hashes[i]) < graphNodeName(dag, hashes[j])
+			})
+		}
+		for _, cs := range children {
+			byLabel(cs)
+		}
+		for _, roots := range rootsByBase {
+			byLabel(roots)
+		}
+
+		prsBySrcBranch := map[string]githost.PullRequest{}
+		if graphPRsFlag {
+			var branches []string
+			for _, n := range dag.Nodes {
+				branches = append(branches, n.LocalBranches...)
+			}
+			var actionErr error
+			action := func() {
+				prs, err := concurrent.Map(context.Background(), branches, func(ctx context.Context, branch string) (githost.PullRequest, error) {
+					pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+					if errors.Is(err, githost.ErrDoesNotExist) {
+						return githost.PullRequest{}, nil
+					}
+					return pr, err
+				})
+				if err != nil {
+					actionErr = err
+					return
+				}
+				for _, pr := range prs {
+					if pr.SourceBranch != "" {
+						prsBySrcBranch[pr.SourceBranch] = pr
+					}
+				}
+			}
+			vocab := host.GetVocabulary()
+			err := runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural), action)
+			if err != nil {
+				return err
+			}
+			if actionErr != nil {
+				return actionErr
+			}
+		}
+
+		var printNode func(hash string, prefix string, connector string, childPrefix string)
+		printNode = func(hash string, prefix string, connector string, childPrefix string) {
+			hereMarker := " "
+			if slices.Contains(dag.Nodes[hash].LocalBranches, currBranch) {
+				hereMarker = "*"
+			}
+			label := formatGraphNode(dag, hash, currBranch, prsBySrcBranch, theme)
+			var others []string
+			for _, p := range sortedKeys(parents[hash])[min(1, len(parents[hash])):] {
+				others = append(others, graphNodeName(dag, p))
+			}
+			if len(others) > 0 {
+				label += " " + theme.TertiaryColor.Render(fmt.Sprintf("(merges %s)", strings.Join(others, ", ")))
+			}
+			fmt.Printf("%s %s%s%s\n", hereMarker, prefix, connector, label)
+
+			for i, child := range children[hash] {
+				if i == len(children[hash])-1 {
+					printNode(child, childPrefix, "└── ", childPrefix+"    ")
+				} else {
+					printNode(child, childPrefix, "├── ", childPrefix+"│   ")
+				}
+			}
+		}
+
+		baseNames := sortedKeys(rootsByBase)
+		// The default branch goes first, it's usually the base of most stacks.
+		sort.SliceStable(baseNames, func(i, j int) bool {
+			return baseNames[i] == defaultBranch && baseNames[j] != defaultBranch
+		})
+		for i, base := range baseNames {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("  %s\n", theme.TertiaryColor.Render(base))
+			roots := rootsByBase[base]
+			for i, root := range roots {
+				if i == len(roots)-1 {
+					printNode(root, "", "└── ", "    ")
+				} else {
+					printNode(root, "", "├── ", "│   ")
+				}
+			}
+		}
+
+		printProblems(stacks, theme)
+		printMergedBranches(mergedInto, squashMergedBranches, theme)
+		return nil
+	},
+}
+
+// graphNodeName returns the branches pointing to a commit from the bottom up, or its
+// hash if it has none.
+func graphNodeName(dag commitgraph.DAG, hash string) string {
+	branches := dag.Nodes[hash].LocalBranches
+	if len(branches) == 0 {
+		return hash
+	}
+	var names []string
+	for i := len(branches) - 1; i >= 0; i-- {
+		names = append(names, branches[i])
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatGraphNode(
+	dag commitgraph.DAG,
+	hash string,
+	currBranch string,
+	prsBySrcBranch map[string]githost.PullRequest,
+	theme config.Theme,
+) string {
+	branches := dag.Nodes[hash].LocalBranches
+	if len(branches) == 0 {
+		return theme.QuaternaryColor.Render(fmt.Sprintf("%s (no branch, stacks fork here)", hash))
+	}
+	var names []string
+	for i := len(branches) - 1; i >= 0; i-- {
+		b := branches[i]
+		name := b
+		if b == currBranch {
+			name = theme.PrimaryColor.Render(b)
+		}
+		if pr, ok := prsBySrcBranch[b]; ok {
+			name += fmt.Sprintf(" (#%d %s)", pr.ID, formatPRState(pr, theme))
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
@@ -23,7 +23,7 @@ var initCmd = &cobra.Command{
 			return err
 		}
 
-		remote, err := git.GetRemote()
+		remote, err := git.GetRemote("origin")
 		if err != nil {
 			return err
 		}
@@ -50,7 +50,60 @@ var initCmd = &cobra.Command{
 			fmt.Println()
 		}
 
-		switch remote.Kind {
+		// Forks conventionally have an upstream remote pointing at the original repo.
+		targetRemote := remote
+		var sourceRepoPath string
+		if upstream, err := git.GetRemote("upstream"); err == nil && upstream.URLPath != remote.URLPath {
+			fmt.Printf("Push branches to origin (%s) and open change requests against upstream (%s)? (y/n): ",
+				remote.URLPath, upstream.URLPath)
+			useUpstream, err := promptUserConfirmation()
+			if err != nil {
+				return err
+			}
+			if useUpstream {
+				targetRemote = upstream
+				sourceRepoPath = remote.URLPath
+			}
+			fmt.Println()
+		}
+
+		kind := targetRemote.Kind
+		if kind == "" {
+			fmt.Printf("Unable to detect which git host %s is.\n", targetRemote.Hostname)
+			fmt.Print("Is it a GitHub Enterprise Server or a self-hosted GitLab instance? (github/gitlab): ")
+			kind, err = promptHostKind()
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+		apiBaseURL := githost.DefaultAPIBaseURL(kind, targetRemote.Hostname)
+		if apiBaseURL != "" {
+			fmt.Printf("Enter the API base URL (default: %s): ", apiBaseURL)
+			input, err := promptUserInput()
+			if err != nil {
+				return err
+			}
+			if input != "" {
+				apiBaseURL = input
+			}
+			fmt.Println()
+		}
+		// Only persist the kind if it can't be detected from the remote url.
+		var hostKind string
+		if targetRemote.Kind == "" {
+			hostKind = string(kind)
+		}
+		var targetRemoteName string
+		if targetRemote.Name != remote.Name {
+			targetRemoteName = targetRemote.Name
+		}
+		hostOpts := githost.Opts{
+			BaseURL:        apiBaseURL,
+			SourceRepoPath: sourceRepoPath,
+		}
+
+		switch kind {
 		case githost.Gitlab:
 			fmt.Print("Enter your GitLab personal access token: ")
 			personalAccessToken, err := promptUserInput()
@@ -58,11 +111,11 @@ var initCmd = &cobra.Command{
 				return err
 			}
 
-			host, err := gitlab.New(personalAccessToken)
+			host, err := gitlab.New(personalAccessToken, hostOpts)
 			if err != nil {
 				return err
 			}
-			repo, err := host.GetRepo(remote.URLPath)
+			repo, err := host.GetRepo(targetRemote.URLPath)
 			if err != nil {
 				return err
 			}
@@ -72,6 +125,9 @@ var initCmd = &cobra.Command{
 					PersonalAccessToken: personalAccessToken,
 				},
 				DefaultBranch: repo.DefaultBranch,
+				HostKind:      hostKind,
+				APIBaseURL:    apiBaseURL,
+				TargetRemote:  targetRemoteName,
 			}
 		case githost.Github:
 			fmt.Println("`git stack` requires a Github personal access token in order to manage pull requests on your behalf.")
@@ -85,7 +141,7 @@ var initCmd = &cobra.Command{
 			fmt.Println("- Repository permissions (Metadata): Read-only")
 			fmt.Println("- Repository permissions (Pull Requests): Read and write ")
 			fmt.Println()
-			fmt.Println("You can create a personal access token at https://github.com/settings/personal-access-tokens/new.")
+			fmt.Printf("You can create a personal access token at https://%s/settings/personal-access-tokens/new.\n", targetRemote.Hostname)
 			fmt.Println()
 			fmt.Print("To continue, enter your Github personal access token: ")
 			personalAccessToken, err := promptUserInput()
@@ -93,12 +149,12 @@ var initCmd = &cobra.Command{
 				return err
 			}
 
-			host, err := github.New(personalAccessToken)
+			host, err := github.New(personalAccessToken, hostOpts)
 			if err != nil {
 				return err
 			}
 
-			repo, err := host.GetRepo(remote.URLPath)
+			repo, err := host.GetRepo(targetRemote.URLPath)
 			if err != nil {
 				return err
 			}
@@ -108,9 +164,12 @@ var initCmd = &cobra.Command{
 					PersonalAccessToken: personalAccessToken,
 				},
 				DefaultBranch: repo.DefaultBranch,
+				HostKind:      hostKind,
+				APIBaseURL:    apiBaseURL,
+				TargetRemote:  targetRemoteName,
 			}
 		default:
-			return fmt.Errorf("unsupported git host %s", remote.Kind)
+			return fmt.Errorf("unsupported git host %s", kind)
 		}
 
 		cfgPath, err := config.Save(cfg)
@@ -144,6 +203,22 @@ func promptUserConfirmation() (bool, error) {
 	}
 }
 
+func promptHostKind() (githost.Kind, error) {
+	input, err := promptUserInput()
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(input) {
+	case "github":
+		return githost.Github, nil
+	case "gitlab":
+		return githost.Gitlab, nil
+	default:
+		return "", fmt.Errorf("invalid input: %s", input)
+	}
+}
+
 func promptUserInput() (string, error) {
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+// jsonVersion is incremented whenever a backwards incompatible change is made to jsonOutput.
+const jsonVersion = 1
+
+var jsonFlag bool
+
+func addJSONFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Print machine-readable JSON output")
+}
+
+// jsonOutput is the output of all commands that support --json. Commands only populate
+// the fields that are relevant to them.
+type jsonOutput struct {
+	Version        int                `json:"version"`
+	Stacks         []jsonStack        `json:"stacks"`
+	Commits        []jsonCommit       `json:"commits,omitempty"`
+	Problems       jsonProblems       `json:"problems"`
+	MergedBranches []jsonMergedBranch `json:"mergedBranches,omitempty"`
+}
+
+type jsonStack struct {
+	Name    string `json:"name"`
+	Base    string `json:"base"`
+	Current bool   `json:"current"`
+	// Ordered from the top of the stack to the bottom, or null if the stack has no total order.
+	TotalOrderedBranches []string `json:"totalOrderedBranches"`
+	// Ordered the same as TotalOrderedBranches, or in reverse lexicographic order
+	// if the stack has no total order.
+	Branches []jsonBranch `json:"branches"`
+}
+
+type jsonBranch struct {
+	Name          string             `json:"name"`
+	Commit        string             `json:"commit"`
+	Current       bool               `json:"current"`
+	ChangeRequest *jsonChangeRequest `json:"changeRequest,omitempty"`
+}
+
+type jsonChangeRequest struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	TargetBranch string `json:"targetBranch"`
+	State        string `json:"state"`
+}
+
+type jsonCommit struct {
+	Hash         string   `json:"hash"`
+	ParentHashes []string `json:"parentHashes"`
+	Branches     []string `json:"branches"`
+	Author       string   `json:"author"`
+	Date         string   `json:"date"`
+	Subject      string   `json:"subject"`
+}
+
+type jsonProblems struct {
+	DivergentStacks        []jsonDivergentStack        `json:"divergentStacks"`
+	PartiallyOrderedStacks []jsonPartiallyOrderedStack `json:"partiallyOrderedStacks"`
+}
+
+type jsonDivergentStack struct {
+	Name         string   `json:"name"`
+	DivergesFrom []string `json:"divergesFrom"`
+}
+
+type jsonPartiallyOrderedStack struct {
+	Name string `json:"name"`
+	// Each entry has length two
+	IncomparableBranches [][]string `json:"incomparableBranches"`
+	Message              string     `json:"message"`
+}
+
+type jsonMergedBranch struct {
+	Name string `json:"name"`
+	// The base branch it's merged into, empty for squash merged branches.
+	MergedInto   string `json:"mergedInto,omitempty"`
+	SquashMerged bool   `json:"squashMerged"`
+}
+
+func printJSON(out jsonOutput) error {
+	out.Version = jsonVersion
+	if out.Stacks == nil {
+		out.Stacks = []jsonStack{}
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json output, err: %v", err)
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// runWithSpinner runs action while displaying a spinner, unless printing JSON output.
+func runWithSpinner(title string, action func()) error {
+	if jsonFlag {
+		action()
+		return nil
+	}
+	return spinner.New().Title(title).Action(action).Run()
+}
+
+func newJSONStacks(
+	stacks []stackparser.Stack, currCommit string, currBranch string,
+	prsBySourceBranch map[string]githost.PullRequest,
+) []jsonStack {
+	var out []jsonStack
+	for _, s := range stacks {
+		js := jsonStack{
+			Name:    s.Name,
+			Base:    s.Base,
+			Current: s.IsCurrent(currCommit),
+		}
+		branches, err := s.TotalOrderedBranches()
+		if err == nil {
+			js.TotalOrderedBranches = branches
+		} else {
+			branches = s.Branches()
+		}
+
+		commitsByBranch := map[string]string{}
+		for _, c := range s.Commits {
+			for _, b := range c.LocalBranches {
+				commitsByBranch[b] = c.Hash
+			}
+		}
+		for _, b := range branches {
+			jb := jsonBranch{
+				Name:    b,
+				Commit:  commitsByBranch[b],
+				Current: b == currBranch,
+			}
+			if pr, ok := prsBySourceBranch[b]; ok {
+				jb.ChangeRequest = &jsonChangeRequest{
+					ID:           pr.ID,
+					Title:        pr.Title,
+					URL:          pr.WebURL,
+					TargetBranch: pr.TargetBranch,
+					State:        string(pr.State),
+				}
+			}
+			js.Branches = append(js.Branches, jb)
+		}
+		out = append(out, js)
+	}
+	return out
+}
+
+func newJSONCommits(log libgit.Log) []jsonCommit {
+	var out []jsonCommit
+	for _, c := range log.Commits {
+		out = append(out, jsonCommit{
+			Hash:         c.Hash,
+			ParentHashes: c.ParentHashes,
+			Branches:     c.LocalBranches,
+			Author:       c.Author,
+			Date:         c.Date,
+			Subject:      c.Subject,
+		})
+	}
+	return out
+}
+
+func newJSONProblems(stacks []stackparser.Stack) jsonProblems {
+	problems := getProblems(stacks)
+	out := jsonProblems{
+		DivergentStacks:        []jsonDivergentStack{},
+		PartiallyOrderedStacks: []jsonPartiallyOrderedStack{},
+	}
+	for _, d := range problems.divergent {
+		out.DivergentStacks = append(out.DivergentStacks, jsonDivergentStack{
+			Name:         d.name,
+			DivergesFrom: d.divergesFrom,
+		})
+	}
+	for _, err := range problems.noTotalOrder {
+		out.PartiallyOrderedStacks = append(out.PartiallyOrderedStacks, jsonPartiallyOrderedStack{
+			Name:                 err.StackName,
+			IncomparableBranches: err.IncomparableBranchPairs,
+			Message:              err.Error(),
+		})
+	}
+	return out
+}
+
+func newJSONMergedBranches(mergedInto map[string]string, squashMergedBranches []string) []jsonMergedBranch {
+	var out []jsonMergedBranch
+	for _, b := range sortedKeys(mergedInto) {
+		out = append(out, jsonMergedBranch{Name: b, MergedInto: mergedInto[b]})
+	}
+	for _, b := range squashMergedBranches {
+		out = append(out, jsonMergedBranch{Name: b, SquashMerged: true})
+	}
+	return out
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/raymondji/git-stack-cli/ui"
+	"github.com/spf13/cobra"
+)
+
+var landAllFlag bool
+
+const (
+	landPollInterval = 2 * time.Second
+	landTimeout      = 5 * time.Minute
+)
+
+func init() {
+	landCmd.Flags().BoolVar(&landAllFlag, "all", false, "Continue landing the remaining branches in the stack")
+}
+
+var landCmd = &cobra.Command{
+	Use:   "land",
+	Short: "Merge the bottom branch of the current stack",
+	Long: "Merges the change request of the bottom branch in the current stack, " +
+		"then restacks the remaining branches onto the stack's base branch and retargets their change requests.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, host := deps.git, deps.host
+
+		method, err := getMergeMethod(deps.repoCfg, deps.remote.Kind)
+		if err != nil {
+			return err
+		}
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+
+		stacks, _, err := parseStacks(git, deps.repoCfg.DefaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		s, err := stackparser.GetCurrent(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		branches, err := s.TotalOrderedBranches()
+		if err != nil {
+			return err
+		}
+		if len(s.DivergesFrom()) > 0 {
+			fmt.Println("error: cannot land divergent stacks")
+			printProblems([]stackparser.Stack{s}, deps.theme)
+			return nil
+		}
+		if !stackparser.GetTree(stacks, s).IsLinear() {
+			return fmt.Errorf("cannot land stacks that share branches with other stacks")
+		}
+
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		// The commit the bottom branch's change request must be at before merging it, set
+		// once the branch has been restacked and pushed.
+		var wantHead string
+		for len(branches) > 0 {
+			bottom := branches[len(branches)-1]
+			remaining := branches[:len(branches)-1]
+
+			var pr githost.PullRequest
+			var actionErr error
+			action := func() {
+				pr, actionErr = landBranch(deps, bottom, s.Base, method, wantHead)
+			}
+			if err := spinner.New().Title(fmt.Sprintf("Landing %s...", bottom)).Action(action).Run(); err != nil {
+				return err
+			}
+			if actionErr != nil {
+				if landAllFlag {
+					fmt.Println("Branches left to land:")
+					fmt.Println(strings.Repeat(" ", 2) + `(use "git stack land --all" to land them)`)
+					for _, b := range branches {
+						fmt.Println(strings.Repeat(" ", 8) + deps.theme.QuaternaryColor.Render(b))
+					}
+				}
+				return actionErr
+			}
+			fmt.Printf("Landed %s (%s)\n", bottom, pr.WebURL)
+
+			action = func() {
+				actionErr = restackLanded(deps, bottom, remaining, s.Base)
+			}
+			if err := spinner.New().Title("Restacking remaining branches...").Action(action).Run(); err != nil {
+				return err
+			}
+			if actionErr != nil {
+				followUp := []string{
+					`use "git stack push --safer-force" to push the restacked branches`,
+					fmt.Sprintf(`use "git branch -D %s" to delete the landed branch`, bottom),
+				}
+				if landAllFlag {
+					followUp = append(followUp, `use "git stack land --all" to land the remaining branches`)
+				}
+				return handleRebaseErr(git, deps.theme, actionErr, followUp...)
+			}
+
+			if currBranch == bottom {
+				currBranch = s.Base
+				if len(remaining) > 0 {
+					currBranch = remaining[len(remaining)-1]
+				}
+			}
+			if err := git.Checkout(currBranch); err != nil {
+				return err
+			}
+			if err := git.DeleteBranchIfExists(bottom); err != nil {
+				return err
+			}
+			benchmarkPoint("landCmd", fmt.Sprintf("landed %s", bottom))
+
+			branches = remaining
+			if !landAllFlag || len(remaining) == 0 {
+				break
+			}
+			hashes, err := git.GetBranchHashes()
+			if err != nil {
+				return err
+			}
+			wantHead = hashes[remaining[len(remaining)-1]]
+		}
+
+		if len(branches) > 0 {
+			fmt.Println()
+			fmt.Println("Remaining branches:")
+			ui.PrintBranchesInStack(
+				branches,
+				true,
+				currBranch,
+				deps.theme,
+				nil,
+				false,
+				host.GetVocabulary(),
+			)
+		}
+		return nil
+	},
+}
+
+func getMergeMethod(repoCfg config.RepoConfig, kind githost.Kind) (githost.MergeMethod, error) {
+	if repoCfg.HostKind != "" {
+		kind = githost.Kind(repoCfg.HostKind)
+	}
+	switch method := githost.MergeMethod(repoCfg.MergeMethod); method {
+	case "":
+		return githost.MergeMethodMerge, nil
+	case githost.MergeMethodRebase:
+		if kind == githost.Gitlab {
+			return "", fmt.Errorf("merge method %s in config is not supported for gitlab, configure fast-forward merges in the project settings instead", method)
+		}
+		return method, nil
+	case githost.MergeMethodMerge, githost.MergeMethodSquash:
+		return method, nil
+	default:
+		return "", fmt.Errorf("invalid merge method in config: %s", method)
+	}
+}
+
+// landBranch merges the change request for branch into base and waits for the merge to complete.
+// If wantHead is set, it first waits for the change request to be updated to that commit, so that
+// commits that were just pushed aren't merged with the old ones.
+func landBranch(deps deps, branch string, base string, method githost.MergeMethod, wantHead string) (githost.PullRequest, error) {
+	host := deps.host
+	vocab := host.GetVocabulary()
+
+	pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+	if errors.Is(err, githost.ErrDoesNotExist) {
+		return githost.PullRequest{}, fmt.Errorf(
+			"%s does not have an open %s (use \"git stack push --open\" to open one)", branch, vocab.ChangeRequestName)
+	} else if err != nil {
+		return githost.PullRequest{}, err
+	}
+	if pr.TargetBranch != base {
+		return githost.PullRequest{}, fmt.Errorf(
+			"%s for %s targets %s instead of %s (use \"git stack push\" to update it)",
+			vocab.ChangeRequestName, branch, pr.TargetBranch, base)
+	}
+
+	deadline := time.Now().Add(landTimeout)
+	for wantHead != "" && pr.HeadCommit != wantHead {
+		if time.Now().After(deadline) {
+			return githost.PullRequest{}, fmt.Errorf("timed out waiting for %s to update to the pushed commits: %s", vocab.ChangeRequestName, pr.WebURL)
+		}
+		time.Sleep(landPollInterval)
+		pr, err = host.GetChangeRequestByID(deps.remote.URLPath, pr.ID)
+		if err != nil {
+			return githost.PullRequest{}, err
+		}
+	}
+
+	pr, err = host.MergeChangeRequest(deps.remote.URLPath, pr, method)
+	if err != nil {
+		return githost.PullRequest{}, err
+	}
+
+	deadline = time.Now().Add(landTimeout)
+	for {
+		switch pr.State {
+		case githost.PullRequestStateMerged:
+			return pr, nil
+		case githost.PullRequestStateClosed:
+			return githost.PullRequest{}, fmt.Errorf("%s was closed without merging: %s", vocab.ChangeRequestName, pr.WebURL)
+		}
+		if time.Now().After(deadline) {
+			return githost.PullRequest{}, fmt.Errorf("timed out waiting for %s to merge: %s", vocab.ChangeRequestName, pr.WebURL)
+		}
+
+		time.Sleep(landPollInterval)
+		pr, err = host.GetChangeRequestByID(deps.remote.URLPath, pr.ID)
+		if err != nil {
+			return githost.PullRequest{}, err
+		}
+	}
+}
+
+// restackLanded moves the remaining branches of a stack onto base after the landed branch
+// below them was merged. Branches are ordered from the top of the stack to the bottom.
+func restackLanded(deps deps, landed string, remaining []string, base string) error {
+	git := deps.git
+	if len(remaining) > 0 {
+		// Retarget first so that the next change request isn't closed if the landed
+		// branch gets deleted on the remote.
+		if err := retargetChangeRequests(deps, getWantTargets(remaining, base)); err != nil {
+			return err
+		}
+	}
+
+	if err := git.Fetch(deps.remote.Name); err != nil {
+		return err
+	}
+	if err := git.FastForward(base, deps.remote.Name+"/"+base); err != nil {
+		return err
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	_, err := git.Rebase(landed, libgit.RebaseOpts{
+		Onto:       base,
+		Branch:     remaining[0],
+		UpdateRefs: true,
+	})
+	if err != nil {
+		return err
+	}
+	return concurrent.ForEach(context.Background(), remaining, func(ctx context.Context, branch string) error {
+		_, err := git.Push(deps.pushRemote.Name, branch, libgit.PushOpts{
+			ForceWithLease:  true,
+			ForceIfIncludes: true,
+		})
+		return err
+	})
+}
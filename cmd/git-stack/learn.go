@@ -60,14 +60,20 @@ var learnCmd = &cobra.Command{
 				"git stack learn --chapter %d --mode=exec", learnChapterFlag,
 			)))
 		case learnModeExec:
-			if err := sample.Cleanup(); err != nil {
+			if err := snapshotBranches(deps.git); err != nil {
+				return err
+			}
+			if err := sample.Cleanup(deps.remote.URLPath, deps.pushRemote.Name); err != nil {
 				return err
 			}
 			if err := sample.Execute(); err != nil {
 				return err
 			}
 		case learnModeClean:
-			if err := sample.Cleanup(); err != nil {
+			if err := snapshotBranches(deps.git); err != nil {
+				return err
+			}
+			if err := sample.Cleanup(deps.remote.URLPath, deps.pushRemote.Name); err != nil {
 				return err
 			}
 		default:
@@ -9,6 +9,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	addJSONFlag(listCmd)
+}
+
 // TODO: add --contains flag to filter by stacks that contain branch X
 var listCmd = &cobra.Command{
 	Use:     "list",
@@ -23,8 +27,9 @@ var listCmd = &cobra.Command{
 
 		benchmarkPoint("listCmd", "got deps")
 
-		var currCommit string
-		var mergedBranches []string
+		var currBranch, currCommit string
+		var mergedInto map[string]string
+		var squashMergedBranches []string
 		var stacks []stackparser.Stack
 		err = concurrent.Run(
 			context.Background(),
@@ -35,15 +40,17 @@ var listCmd = &cobra.Command{
 			},
 			func(ctx context.Context) error {
 				var err error
-				mergedBranches, err = git.GetMergedBranches(defaultBranch)
+				currBranch, err = git.GetCurrentBranch()
+				return err
+			},
+			func(ctx context.Context) error {
+				var err error
+				mergedInto, err = getMergedBranches(git, defaultBranch)
 				return err
 			},
 			func(ctx context.Context) error {
-				log, err := git.LogAll(defaultBranch)
-				if err != nil {
-					return err
-				}
-				stacks, err = stackparser.ParseStacks(log)
+				var err error
+				stacks, squashMergedBranches, err = parseStacks(git, defaultBranch)
 				return err
 			},
 		)
@@ -51,8 +58,15 @@ var listCmd = &cobra.Command{
 			return err
 		}
 		benchmarkPoint("listCmd", "got curr commit and stack stackparser")
+		if jsonFlag {
+			return printJSON(jsonOutput{
+				Stacks:         newJSONStacks(stacks, currCommit, currBranch, nil),
+				Problems:       newJSONProblems(stacks),
+				MergedBranches: newJSONMergedBranches(mergedInto, squashMergedBranches),
+			})
+		}
 		defer func() {
-			printMergedBranches(mergedBranches, defaultBranch, theme)
+			printMergedBranches(mergedInto, squashMergedBranches, theme)
 		}()
 		defer func() {
 			printProblems(stacks, theme)
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"slices"
 
 	"github.com/raymondji/git-stack-cli/concurrent"
 	"github.com/raymondji/git-stack-cli/stackparser"
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	addJSONFlag(logCmd)
+}
+
 var logCmd = &cobra.Command{
 	Use:   "log [stack]",
 	Short: "Log commits in the stack",
@@ -24,7 +28,8 @@ var logCmd = &cobra.Command{
 
 		var currBranch, currCommit string
 		var stacks []stackparser.Stack
-		var mergedBranches []string
+		var mergedInto map[string]string
+		var squashMergedBranches []string
 		err = concurrent.Run(
 			context.Background(),
 			func(ctx context.Context) error {
@@ -34,7 +39,7 @@ var logCmd = &cobra.Command{
 			},
 			func(ctx context.Context) error {
 				var err error
-				mergedBranches, err = git.GetMergedBranches(defaultBranch)
+				mergedInto, err = getMergedBranches(git, defaultBranch)
 				return err
 			},
 			func(ctx context.Context) error {
@@ -43,11 +48,8 @@ var logCmd = &cobra.Command{
 				return err
 			},
 			func(ctx context.Context) error {
-				log, err := git.LogAll(defaultBranch)
-				if err != nil {
-					return err
-				}
-				stacks, err = stackparser.ParseStacks(log)
+				var err error
+				stacks, squashMergedBranches, err = parseStacks(git, defaultBranch)
 				return err
 			},
 		)
@@ -56,13 +58,16 @@ var logCmd = &cobra.Command{
 		}
 		benchmarkPoint("logCmd", "got curr commit, curr branch, and stack stackparser")
 
-		var stack stackparser.Stack
+		var tree stackparser.Tree
 		if len(args) == 0 {
-			if slices.Contains(mergedBranches, currBranch) {
-				fmt.Printf("error: the current branch is not a valid stack (it's merged into %s)\n", defaultBranch)
+			if msg := mergedError(currBranch, defaultBranch, mergedInto, squashMergedBranches); msg != "" {
+				if jsonFlag {
+					return errors.New(msg)
+				}
+				fmt.Println("error: " + msg)
 				return nil
 			}
-			stack, err = stackparser.GetCurrent(stacks, currCommit)
+			tree, err = stackparser.GetCurrentTree(stacks, currCommit)
 			if err != nil {
 				return err
 			}
@@ -71,7 +76,7 @@ var logCmd = &cobra.Command{
 			var found bool
 			for _, s := range stacks {
 				if s.Name == wantStack {
-					stack = s
+					tree = stackparser.GetTree(stacks, s)
 					found = true
 				}
 			}
@@ -79,12 +84,34 @@ var logCmd = &cobra.Command{
 				return fmt.Errorf("no stack named: %s", wantStack)
 			}
 		}
+		benchmarkPoint("logCmd", "got desired stack")
+		var tips []string
+		for _, s := range tree.Stacks {
+			tips = append(tips, s.Name)
+		}
+		if jsonFlag {
+			log, err := git.Log(tree.Base(), tips...)
+			if err != nil {
+				return err
+			}
+			return printJSON(jsonOutput{
+				Stacks:   newJSONStacks(tree.Stacks, currCommit, currBranch, nil),
+				Commits:  newJSONCommits(log),
+				Problems: newJSONProblems(tree.Stacks),
+			})
+		}
+
 		defer func() {
-			printProblems([]stackparser.Stack{stack}, deps.theme)
+			printProblems(tree.Stacks, deps.theme)
 		}()
-		benchmarkPoint("logCmd", "got desired stack")
-		if err := git.LogOneline(defaultBranch, stack.Name); err != nil {
-			return err
+		if tree.IsLinear() {
+			if err := git.LogOneline(tree.Base(), tree.Stacks[0].Name); err != nil {
+				return err
+			}
+		} else {
+			if err := git.LogGraph(tree.Base(), tips...); err != nil {
+				return err
+			}
 		}
 		benchmarkPoint("logCmd", "done")
 
@@ -14,15 +14,39 @@ var (
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&benchmarkFlag, "benchmark", false, "Benchmark commands")
 	rootCmd.AddCommand(
+		abortCmd,
+		absorbCmd,
+		baseCmd,
+		bottomCmd,
 		branchCmd,
+		checkoutCmd,
+		checksCmd,
+		continueCmd,
+		createCmd,
+		deleteCmd,
+		diffCmd,
+		downCmd,
+		editCmd,
 		fixupCmd,
+		foldCmd,
+		graphCmd,
 		initCmd,
+		landCmd,
 		learnCmd,
 		listCmd,
 		logCmd,
 		pushCmd,
 		rebaseCmd,
+		renameCmd,
+		reorderCmd,
+		restackCmd,
+		splitCmd,
+		statusCmd,
 		switchCmd,
+		syncCmd,
+		topCmd,
+		undoCmd,
+		upCmd,
 		versionCmd,
 	)
 }
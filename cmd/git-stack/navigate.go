@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up [n]",
+	Short: "Switch to the branch n above the current one in the stack (default 1)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseSteps(args)
+		if err != nil {
+			return err
+		}
+		return navigate(func(branches []string, i int) (string, error) {
+			if i == 0 {
+				return "", fmt.Errorf("%s is already at the top of the stack", branches[i])
+			} else if i-n < 0 {
+				return "", fmt.Errorf("cannot move up %d, %s has %d branch(es) above it", n, branches[i], i)
+			}
+			return branches[i-n], nil
+		}, true)
+	},
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Switch to the branch n below the current one in the stack (default 1)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseSteps(args)
+		if err != nil {
+			return err
+		}
+		return navigate(func(branches []string, i int) (string, error) {
+			if i == len(branches)-1 {
+				return "", fmt.Errorf("%s is already at the bottom of the stack", branches[i])
+			} else if i+n >= len(branches) {
+				return "", fmt.Errorf("cannot move down %d, %s has %d branch(es) below it", n, branches[i], len(branches)-1-i)
+			}
+			return branches[i+n], nil
+		}, false)
+	},
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Switch to the top branch of the stack",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return navigate(func(branches []string, i int) (string, error) {
+			return branches[0], nil
+		}, true)
+	},
+}
+
+var bottomCmd = &cobra.Command{
+	Use:   "bottom",
+	Short: "Switch to the bottom branch of the stack",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return navigate(func(branches []string, i int) (string, error) {
+			return branches[len(branches)-1], nil
+		}, false)
+	},
+}
+
+func parseSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of branches: %s", args[0])
+	}
+	return n, nil
+}
+
+// navigate checks out the branch chosen by pick, given the current stack's branches ordered
+// from the top of the stack to the bottom and the index of the current branch.
+// If up is true, pick may choose a branch above the current one, which is ambiguous when
+// the current branch is shared by multiple stacks.
+func navigate(pick func(branches []string, i int) (string, error), up bool) error {
+	deps, err := initDeps()
+	if err != nil {
+		return err
+	}
+	git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
+
+	var currBranch string
+	var stacks []stackparser.Stack
+	err = concurrent.Run(
+		context.Background(),
+		func(ctx context.Context) error {
+			var err error
+			currBranch, err = git.GetCurrentBranch()
+			return err
+		},
+		func(ctx context.Context) error {
+			var err error
+			stacks, _, err = parseStacks(git, defaultBranch)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if currBranch == "HEAD" {
+		return fmt.Errorf("HEAD is detached, check out a branch in a stack first")
+	}
+
+	currStacks := getStacksWithBranch(stacks, currBranch)
+	if len(currStacks) == 0 {
+		return fmt.Errorf("%s is not a branch in any stack", currBranch)
+	}
+	if up && len(currStacks) > 1 {
+		var names []string
+		for _, s := range currStacks {
+			names = append(names, s.Name)
+		}
+		return fmt.Errorf("%s is shared by multiple stacks (%s), use `git stack switch` to choose one",
+			currBranch, strings.Join(names, ", "))
+	}
+
+	// Stacks that share a branch also share every branch below it, so any of them will do.
+	s := currStacks[0]
+	branches, err := s.TotalOrderedBranches()
+	var errNoTotalOrder stackparser.NoTotalOrderError
+	if errors.As(err, &errNoTotalOrder) {
+		return fmt.Errorf("stack %s is partially ordered, use `git stack switch -b` to choose a branch", s.Name)
+	} else if err != nil {
+		return err
+	}
+
+	target, err := pick(branches, slices.Index(branches, currBranch))
+	if err != nil {
+		return err
+	}
+	if target == currBranch {
+		fmt.Printf("Already on '%s'\n", target)
+		return nil
+	}
+	if err := git.Checkout(target); err != nil {
+		return err
+	}
+	fmt.Printf("Switched to branch '%s'\n", target)
+	return nil
+}
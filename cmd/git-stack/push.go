@@ -7,7 +7,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/charmbracelet/huh/spinner"
 	"github.com/raymondji/git-stack-cli/concurrent"
 	"github.com/raymondji/git-stack-cli/githost"
 	"github.com/raymondji/git-stack-cli/libgit"
@@ -25,6 +24,7 @@ func init() {
 	pushCmd.Flags().BoolVarP(&pushSaferForceFlag, "safer-force", "f", false, "see git push --force-with-lease and --force-if-includes")
 	pushCmd.Flags().BoolVarP(&pushForceFlag, "force", "F", false, "see git push --force")
 	pushCmd.Flags().BoolVarP(&pushCreatePRsFlag, "open", "o", false, "Open new PRs/MRs. Existing ones are always updated.")
+	addJSONFlag(pushCmd)
 }
 
 var pushCmd = &cobra.Command{
@@ -39,11 +39,7 @@ var pushCmd = &cobra.Command{
 		git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
 
 		ctx := context.Background()
-		log, err := git.LogAll(defaultBranch)
-		if err != nil {
-			return err
-		}
-		stacks, err := stackparser.ParseStacks(log)
+		stacks, _, err := parseStacks(git, defaultBranch)
 		if err != nil {
 			return err
 		}
@@ -55,28 +51,31 @@ var pushCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		s, err := stackparser.GetCurrent(stacks, currCommit)
+		tree, err := stackparser.GetCurrentTree(stacks, currCommit)
 		if err != nil {
 			return err
 		}
+		if len(tree.DivergesFrom()) > 0 {
+			if jsonFlag {
+				return fmt.Errorf("cannot push divergent stacks")
+			}
+			fmt.Println("error: cannot push divergent stacks")
+			printProblems(tree.Stacks, deps.theme)
+			return nil
+		}
 
-		wantTargets := map[string]string{}
-		branches, err := s.TotalOrderedBranches()
+		parents, err := tree.Parents()
 		if err != nil {
 			return err
 		}
-		if len(s.DivergesFrom()) > 0 {
-			fmt.Println("error: cannot push divergent stacks")
-			printProblems([]stackparser.Stack{s}, deps.theme)
-			return nil
-		}
-		for i, b := range branches {
-			if i == len(branches)-1 {
-				wantTargets[b] = defaultBranch
-			} else {
-				wantTargets[b] = branches[i+1]
+		branches := tree.Branches()
+		if tree.IsLinear() {
+			branches, err = tree.Stacks[0].TotalOrderedBranches()
+			if err != nil {
+				return err
 			}
 		}
+		wantTargets := getTreeWantTargets(parents, tree.Base())
 
 		pushStack := func() ([]githost.PullRequest, error) {
 			// Before pushing branches, reset the target branch on any existing MRs if they don't match what we want.
@@ -97,7 +96,7 @@ var pushCmd = &cobra.Command{
 						Title:        pr.Title,
 						Description:  pr.Description,
 						SourceBranch: branch,
-						TargetBranch: defaultBranch,
+						TargetBranch: tree.Base(),
 					})
 				}
 
@@ -115,7 +114,7 @@ var pushCmd = &cobra.Command{
 
 			// Push all branches.
 			err = concurrent.ForEach(ctx, branches, func(ctx context.Context, branch string) error {
-				_, err := git.Push(branch, libgit.PushOpts{
+				_, err := git.Push(deps.pushRemote.Name, branch, libgit.PushOpts{
 					Force:           pushForceFlag,
 					ForceWithLease:  pushSaferForceFlag,
 					ForceIfIncludes: pushSaferForceFlag,
@@ -148,17 +147,7 @@ var pushCmd = &cobra.Command{
 			}
 
 			// Update PRs with correct target branches and stack info.
-			return concurrent.Map(ctx, prs, func(ctx context.Context, pr githost.PullRequest) (githost.PullRequest, error) {
-				desc := formatPullRequestDescription(pr, prs)
-				pr, err := host.UpdateChangeRequest(deps.remote.URLPath, githost.PullRequest{
-					ID:           pr.ID,
-					Title:        pr.Title,
-					Description:  desc,
-					SourceBranch: pr.SourceBranch,
-					TargetBranch: wantTargets[pr.SourceBranch],
-				})
-				return pr, err
-			})
+			return updateStackChangeRequests(deps, prs, parents, wantTargets, tree.IsLinear())
 		}
 
 		var prs []githost.PullRequest
@@ -166,7 +155,7 @@ var pushCmd = &cobra.Command{
 		action := func() {
 			prs, actionErr = pushStack()
 		}
-		if err = spinner.New().Title("Pushing stack...").Action(action).Run(); err != nil {
+		if err = runWithSpinner("Pushing stack...", action); err != nil {
 			return err
 		}
 		if actionErr != nil {
@@ -176,21 +165,58 @@ var pushCmd = &cobra.Command{
 		for _, pr := range prs {
 			prsBySourceBranch[pr.SourceBranch] = pr
 		}
+		if jsonFlag {
+			return printJSON(jsonOutput{
+				Stacks:   newJSONStacks(tree.Stacks, currCommit, currBranch, prsBySourceBranch),
+				Problems: newJSONProblems(tree.Stacks),
+			})
+		}
 
 		fmt.Println("Pushed branches:")
-		ui.PrintBranchesInStack(
-			branches,
-			true,
-			currBranch,
-			deps.theme,
-			prsBySourceBranch,
-			true,
-			host.GetVocabulary(),
-		)
+		if tree.IsLinear() {
+			ui.PrintBranchesInStack(
+				branches,
+				true,
+				currBranch,
+				deps.theme,
+				prsBySourceBranch,
+				true,
+				host.GetVocabulary(),
+			)
+		} else {
+			ui.PrintBranchTree(
+				parents,
+				currBranch,
+				deps.theme,
+				prsBySourceBranch,
+				true,
+				host.GetVocabulary(),
+			)
+		}
 		return nil
 	},
 }
 
+// updateStackChangeRequests sets the target branch of each change request and refreshes the
+// stack section of its description. prs are ordered like the branches of the stack.
+func updateStackChangeRequests(
+	deps deps, prs []githost.PullRequest, parents map[string]string, wantTargets map[string]string, linear bool,
+) ([]githost.PullRequest, error) {
+	return concurrent.Map(context.Background(), prs, func(ctx context.Context, pr githost.PullRequest) (githost.PullRequest, error) {
+		desc := formatPullRequestDescription(pr, prs)
+		if !linear {
+			desc = formatTreePullRequestDescription(pr, prs, parents)
+		}
+		return deps.host.UpdateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+			ID:           pr.ID,
+			Title:        pr.Title,
+			Description:  desc,
+			SourceBranch: pr.SourceBranch,
+			TargetBranch: wantTargets[pr.SourceBranch],
+		})
+	})
+}
+
 func formatPullRequestDescription(
 	currPR githost.PullRequest, prs []githost.PullRequest,
 ) string {
@@ -218,14 +244,53 @@ func formatPullRequestDescription(
 		newStackDesc = "This is **part of a stack**:\n" + strings.Join(newStackDescParts, "\n")
 	}
 
+	return replaceStackDescription(currPR.Description, newStackDesc)
+}
+
+// formatTreePullRequestDescription is like formatPullRequestDescription, for stacks that
+// share branches. The stack is rendered as a nested list starting from the bottom.
+func formatTreePullRequestDescription(
+	currPR githost.PullRequest, prs []githost.PullRequest, parents map[string]string,
+) string {
+	prsBySourceBranch := slices.ToMap(prs, func(pr githost.PullRequest) string {
+		return pr.SourceBranch
+	})
+	children := stackparser.Children(parents)
+
+	var newStackDescParts []string
+	var visit func(branch string, depth int)
+	visit = func(branch string, depth int) {
+		if pr, ok := prsBySourceBranch[branch]; ok {
+			var prefix string
+			if branch == currPR.SourceBranch {
+				prefix = "**Current**: "
+			}
+			newStackDescParts = append(newStackDescParts, fmt.Sprintf(
+				"%s- %s%s", strings.Repeat("  ", depth), prefix, pr.MarkdownWebURL))
+			depth++
+		}
+		for _, child := range children[branch] {
+			visit(child, depth)
+		}
+	}
+	for _, root := range children[""] {
+		visit(root, 0)
+	}
+
+	newStackDesc := "This is **part of a stack**:\n" + strings.Join(newStackDescParts, "\n")
+	return replaceStackDescription(currPR.Description, newStackDesc)
+}
+
+// replaceStackDescription replaces the section of desc generated by git stack with newStackDesc.
+func replaceStackDescription(desc string, newStackDesc string) string {
 	beginMarker := "<!-- DO NOT EDIT: generated by git stack push (start)-->"
 	endMarker := "<!-- DO NOT EDIT: generated by git stack push (end) -->"
 	newSection := fmt.Sprintf("%s\n------\n%s\n%s", beginMarker, newStackDesc, endMarker)
 	sectionPattern := regexp.MustCompile(`(?s)` + regexp.QuoteMeta(beginMarker) + `.*?` + regexp.QuoteMeta(endMarker))
 
-	if sectionPattern.MatchString(currPR.Description) {
-		return sectionPattern.ReplaceAllString(currPR.Description, newSection)
+	if sectionPattern.MatchString(desc) {
+		return sectionPattern.ReplaceAllString(desc, newSection)
 	} else {
-		return fmt.Sprintf("%s\n\n%s", strings.TrimSpace(currPR.Description), newSection)
+		return fmt.Sprintf("%s\n\n%s", strings.TrimSpace(desc), newSection)
 	}
 }
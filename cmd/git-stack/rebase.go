@@ -33,7 +33,8 @@ var rebaseCmd = &cobra.Command{
 		}
 		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
 		var currBranch, currCommit string
-		var log libgit.Log
+		var stacks []stackparser.Stack
+		var squashMergedBranches []string
 		err = concurrent.Run(
 			context.Background(),
 			func(ctx context.Context) error {
@@ -48,17 +49,13 @@ var rebaseCmd = &cobra.Command{
 			},
 			func(ctx context.Context) error {
 				var err error
-				log, err = git.LogAll(defaultBranch)
+				stacks, squashMergedBranches, err = parseStacks(git, defaultBranch)
 				return err
 			},
 		)
 		if err != nil {
 			return err
 		}
-		stacks, err := stackparser.ParseStacks(log)
-		if err != nil {
-			return err
-		}
 		currStack, err := stackparser.GetCurrent(stacks, currCommit)
 		if err != nil {
 			return err
@@ -71,6 +68,9 @@ var rebaseCmd = &cobra.Command{
 			if len(currStack.DivergesFrom()) > 0 {
 				return fmt.Errorf("warning: rebasing may lose the association between divergent stacks, use --force to allow")
 			}
+			if !stackparser.GetTree(stacks, currStack).IsLinear() {
+				return fmt.Errorf("warning: rebasing may lose the association between stacks that share branches, use --force to allow")
+			}
 		}
 
 		rebaseOpts := libgit.RebaseOpts{
@@ -79,13 +79,32 @@ var rebaseCmd = &cobra.Command{
 			Interactive: rebaseInteractiveFlag,
 			KeepBase:    rebaseKeepBaseFlag,
 		}
-		newBase := defaultBranch
+		newBase := currStack.Base
 		if len(args) == 1 {
 			newBase = args[0]
 		}
-		if _, err := git.Rebase(newBase, rebaseOpts); err != nil {
+		upstream := newBase
+		if !rebaseKeepBaseFlag {
+			// Drop the commits of any squash merged branches, otherwise they would be
+			// replayed on top of their own squashed commit.
+			for i := len(squashMergedBranches) - 1; i >= 0; i-- {
+				hash, err := git.GetShortCommitHash(squashMergedBranches[i])
+				if err != nil {
+					return err
+				}
+				if _, ok := currStack.Commits[hash]; ok {
+					upstream = squashMergedBranches[i]
+					rebaseOpts.Onto = newBase
+					break
+				}
+			}
+		}
+		if err := snapshotBranches(git); err != nil {
 			return err
 		}
+		if _, err := git.Rebase(upstream, rebaseOpts); err != nil {
+			return handleRebaseErr(git, deps.theme, err)
+		}
 		if !rebaseInteractiveFlag {
 			fmt.Printf("Successfully rebased %s on %s\n", currStack.Name, newBase)
 		}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a branch in a stack and move its change request",
+	Long: "Renames the branch locally and remotely. Neither GitHub nor GitLab can change the source branch of " +
+		"an existing change request, so it is closed and reopened from the new branch with the same title " +
+		"and description, linking the two.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
+		vocab := host.GetVocabulary()
+		oldName, newName := args[0], args[1]
+
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		if _, ok := hashes[oldName]; !ok {
+			return fmt.Errorf("no branch named: %s", oldName)
+		}
+		if _, ok := hashes[newName]; ok {
+			return fmt.Errorf("a branch named %s already exists", newName)
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currStacks := getStacksWithBranch(stacks, oldName)
+		if len(currStacks) == 0 {
+			return fmt.Errorf("%s is not a branch in any stack", oldName)
+		}
+		tree := stackparser.GetTree(stacks, currStacks[0])
+		parents, err := tree.Parents()
+		if err != nil {
+			return err
+		}
+		branches := tree.Branches()
+		if tree.IsLinear() {
+			branches, err = tree.Stacks[0].TotalOrderedBranches()
+			if err != nil {
+				return err
+			}
+		}
+		remoteRef := fmt.Sprintf("refs/remotes/%s/%s", deps.pushRemote.Name, oldName)
+		remoteRefs, err := git.GetRefs(remoteRef)
+		if err != nil {
+			return err
+		}
+		pushed := slices.Contains(remoteRefs, remoteRef)
+
+		var oldPR githost.PullRequest
+		hasPR := true
+		var actionErr error
+		action := func() {
+			oldPR, actionErr = host.GetChangeReqeuest(deps.remote.URLPath, oldName)
+			if errors.Is(actionErr, githost.ErrDoesNotExist) {
+				hasPR = false
+				actionErr = nil
+			}
+		}
+		if err := runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestName), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		if err := git.RenameBranch(oldName, newName); err != nil {
+			return err
+		}
+		fmt.Printf("Renamed %s to %s\n", oldName, newName)
+		if !pushed && !hasPR {
+			return nil
+		}
+
+		// The stack as it is after the rename.
+		rename := func(b string) string {
+			if b == oldName {
+				return newName
+			}
+			return b
+		}
+		renamedParents := map[string]string{}
+		for b, p := range parents {
+			renamedParents[rename(b)] = rename(p)
+		}
+		for i, b := range branches {
+			branches[i] = rename(b)
+		}
+		wantTargets := getTreeWantTargets(renamedParents, tree.Base())
+
+		var newPR githost.PullRequest
+		action = func() {
+			if _, actionErr = git.Push(deps.pushRemote.Name, newName, libgit.PushOpts{}); actionErr != nil {
+				return
+			}
+			if hasPR {
+				newPR, actionErr = host.CreateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+					Title: oldPR.Title,
+					Description: fmt.Sprintf("Moved from %s after renaming the branch from `%s`.\n\n%s",
+						oldPR.MarkdownWebURL, oldName, oldPR.Description),
+					SourceBranch: newName,
+					TargetBranch: oldPR.TargetBranch,
+				})
+				if actionErr != nil {
+					return
+				}
+				// Also retargets the change requests above, before the old branch is deleted
+				// on the remote, which would otherwise close them.
+				if actionErr = refreshStackChangeRequests(deps, branches, renamedParents, wantTargets, tree.IsLinear()); actionErr != nil {
+					return
+				}
+				oldPR, actionErr = host.UpdateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+					ID:    oldPR.ID,
+					Title: oldPR.Title,
+					Description: fmt.Sprintf("Moved to %s after renaming the branch to `%s`.\n\n%s",
+						newPR.MarkdownWebURL, newName, oldPR.Description),
+					SourceBranch: oldName,
+					TargetBranch: oldPR.TargetBranch,
+				})
+				if actionErr != nil {
+					return
+				}
+				if _, actionErr = host.CloseChangeRequest(deps.remote.URLPath, oldPR); actionErr != nil {
+					return
+				}
+			}
+			actionErr = git.DeleteRemoteBranchIfExists(deps.pushRemote.Name, oldName)
+		}
+		if err := runWithSpinner(fmt.Sprintf("Moving %s...", vocab.ChangeRequestName), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		fmt.Printf("Pushed %s and deleted %s on %s\n", newName, oldName, deps.pushRemote.Name)
+		if hasPR {
+			fmt.Printf("Moved %s %s to %s\n", vocab.ChangeRequestName, oldPR.WebURL, newPR.WebURL)
+		}
+		return nil
+	},
+}
+
+// refreshStackChangeRequests updates the existing change requests of the given branches
+// with their target branches and the current stack.
+func refreshStackChangeRequests(
+	deps deps, branches []string, parents map[string]string, wantTargets map[string]string, linear bool,
+) error {
+	prs, err := concurrent.Map(context.Background(), branches, func(ctx context.Context, branch string) (githost.PullRequest, error) {
+		pr, err := deps.host.GetChangeReqeuest(deps.remote.URLPath, branch)
+		if errors.Is(err, githost.ErrDoesNotExist) {
+			return githost.PullRequest{}, nil
+		}
+		return pr, err
+	})
+	if err != nil {
+		return err
+	}
+	prs = slices.DeleteFunc(prs, func(pr githost.PullRequest) bool {
+		return pr.ID == 0
+	})
+	_, err = updateStackChangeRequests(deps, prs, parents, wantTargets, linear)
+	return err
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var reorderCmd = &cobra.Command{
+	Use:   "reorder [branches...]",
+	Short: "Reorder the branches in the current stack",
+	Long: "Takes the new order of the branches from the top of the stack to the bottom, " +
+		"or prompts for it if no branches are given. Each branch's commits are cherry-picked " +
+		"onto the branch below it in the new order. If any branch conflicts, no branches are changed.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch := deps.git, deps.repoCfg.DefaultBranch
+
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		if currBranch == "HEAD" {
+			currBranch = currCommit
+		}
+		s, err := stackparser.GetCurrent(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		if len(s.DivergesFrom()) > 0 {
+			return fmt.Errorf("cannot reorder divergent stacks")
+		}
+		if !stackparser.GetTree(stacks, s).IsLinear() {
+			return fmt.Errorf("cannot reorder stacks that share branches with other stacks")
+		}
+		branches, err := s.TotalOrderedBranches()
+		if err != nil {
+			return err
+		}
+
+		var newOrder []string
+		if len(args) > 0 {
+			newOrder = args
+			sortedOld, sortedNew := slices.Clone(branches), slices.Clone(newOrder)
+			slices.Sort(sortedOld)
+			slices.Sort(sortedNew)
+			if !slices.Equal(sortedOld, sortedNew) {
+				return fmt.Errorf("the new order must contain each branch in the stack exactly once: %s",
+					strings.Join(branches, ", "))
+			}
+		} else {
+			newOrder, err = promptBranchOrder(branches)
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Equal(branches, newOrder) {
+			fmt.Println("Stack is already in that order")
+			return nil
+		}
+
+		// The commits of each branch are the ones between it and the branch below it.
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		// Keep the stack where it forks from its base, moving it is left to git stack rebase.
+		forkPoint, err := git.GetMergeBase(s.Base, branches[len(branches)-1])
+		if err != nil {
+			return err
+		}
+		oldParents := map[string]string{}
+		for i, b := range branches {
+			if i == len(branches)-1 {
+				oldParents[b] = forkPoint
+			} else {
+				oldParents[b] = hashes[branches[i+1]]
+			}
+		}
+
+		// Build the new commit chain on a detached HEAD, so that no branches change
+		// unless every branch applies cleanly.
+		if err := git.Checkout(forkPoint); err != nil {
+			return err
+		}
+		// Return to the original branch if anything below fails, so that the repo isn't
+		// left on a detached HEAD or partway through a cherry-pick.
+		detached := true
+		defer func() {
+			if !detached || err == nil {
+				return
+			}
+			if inProgress, checkErr := git.IsCherryPickInProgress(); checkErr != nil {
+				err = fmt.Errorf("%v, and failed to clean up, err: %v", err, checkErr)
+				return
+			} else if inProgress {
+				if abortErr := git.CherryPickAbort(); abortErr != nil {
+					err = fmt.Errorf("%v, and failed to clean up, err: %v", err, abortErr)
+					return
+				}
+			}
+			if checkoutErr := git.Checkout(currBranch); checkoutErr != nil {
+				err = fmt.Errorf("%v, and failed to return to %s, err: %v", err, currBranch, checkoutErr)
+			}
+		}()
+		// Branches that conflict are skipped so that conflicts in the remaining branches
+		// can be reported too.
+		newTips := map[string]string{}
+		conflicts := map[string][]string{}
+		for i := len(newOrder) - 1; i >= 0; i-- {
+			b := newOrder[i]
+			if err := git.CherryPick(oldParents[b], hashes[b]); err != nil {
+				files, filesErr := git.GetConflictedFiles()
+				if filesErr != nil {
+					return filesErr
+				}
+				if len(files) == 0 {
+					return err
+				}
+				if err := git.CherryPickAbort(); err != nil {
+					return err
+				}
+				conflicts[b] = files
+				continue
+			}
+			newTips[b], err = git.GetShortCommitHash("HEAD")
+			if err != nil {
+				return err
+			}
+		}
+		if len(conflicts) > 0 {
+			if err := git.Checkout(currBranch); err != nil {
+				return err
+			}
+			detached = false
+			fmt.Println("Conflicts while reordering, no branches were changed:")
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack rebase -i" to reorder the commits and resolve the conflicts by hand)`)
+			for i := len(newOrder) - 1; i >= 0; i-- {
+				files, ok := conflicts[newOrder[i]]
+				if !ok {
+					continue
+				}
+				msg := fmt.Sprintf("%s: %s", newOrder[i], strings.Join(files, ", "))
+				fmt.Println(strings.Repeat(" ", 8) + deps.theme.QuaternaryColor.Render(msg))
+			}
+			return fmt.Errorf("failed to reorder stack %s", s.Name)
+		}
+
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+		for _, b := range newOrder {
+			if err := git.UpdateRef("refs/heads/"+b, newTips[b]); err != nil {
+				return err
+			}
+		}
+		if err := git.Checkout(currBranch); err != nil {
+			return err
+		}
+		detached = false
+		fmt.Printf("Reordered stack %s\n", s.Name)
+		fmt.Println(strings.Repeat(" ", 2) + `(use "git stack push --safer-force" to update the branches and their change requests)`)
+		fmt.Println()
+		return printBranches(deps, "")
+	},
+}
+
+// promptBranchOrder asks for the new order one branch at a time, starting from the bottom of
+// the stack. Branches are ordered from the top of the stack to the bottom.
+func promptBranchOrder(branches []string) ([]string, error) {
+	remaining := slices.Clone(branches)
+	var newOrder []string
+	for len(remaining) > 1 {
+		var opts []huh.Option[string]
+		for _, b := range remaining {
+			opts = append(opts, huh.NewOption(b, b))
+		}
+		title := "Choose the bottom branch"
+		if len(newOrder) > 0 {
+			title = fmt.Sprintf("Choose the branch above %s", newOrder[0])
+		}
+		var next string
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(title).
+					Options(opts...).
+					Value(&next),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return nil, err
+		}
+		newOrder = append([]string{next}, newOrder...)
+		remaining = slices.DeleteFunc(remaining, func(b string) bool { return b == next })
+	}
+	return append(remaining, newOrder...), nil
+}
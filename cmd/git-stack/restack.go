@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var restackCmd = &cobra.Command{
+	Use:   "restack",
+	Short: "Finish editing a branch by rebasing the branches above it",
+	Long: "Rebases the branches above the branch checked out with git stack edit onto its new commits, " +
+		"then checks out the branch you were on before editing.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, theme := deps.git, deps.repoCfg.DefaultBranch, deps.theme
+
+		state, ok, err := git.GetEditState()
+		if err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("no branch is being edited (use \"git stack edit <branch>\" to start editing one)")
+		}
+		if _, ok, err := git.GetRebaseState(); err != nil {
+			return err
+		} else if ok {
+			return fmt.Errorf("a rebase is in progress (use \"git stack continue\" or \"git stack abort\" to finish it first)")
+		}
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		for _, b := range []string{state.Branch, state.Top, state.ReturnTo} {
+			if _, ok := hashes[b]; !ok {
+				if err := git.UnsetEditState(); err != nil {
+					return err
+				}
+				return fmt.Errorf("%s no longer exists, stopped editing %s", b, state.Branch)
+			}
+		}
+
+		// Nothing to rebase if the edited branch is unchanged, or if an earlier restack already
+		// rebased onto it, e.g. after resolving conflicts.
+		mergeBase, err := git.GetMergeBase(state.Branch, state.Top)
+		if err != nil {
+			return err
+		}
+		var leftBehind []string
+		if mergeBase != hashes[state.Branch] {
+			// Only the branches below state.Top are rebased, other stacks on the old commits stay there.
+			// The edited branch is its own stack until then.
+			stacks, _, err := parseStacks(git, defaultBranch)
+			if err != nil {
+				return err
+			}
+			originalCommit, err := git.GetShortCommitHash(state.OriginalCommit)
+			if err != nil {
+				return err
+			}
+			for _, s := range stacks {
+				if _, ok := s.Commits[originalCommit]; ok && s.Name != state.Top && s.Name != state.Branch {
+					leftBehind = append(leftBehind, s.Name)
+				}
+			}
+
+			if err := snapshotBranches(git); err != nil {
+				return err
+			}
+			_, err = git.Rebase(state.OriginalCommit, libgit.RebaseOpts{
+				Onto:       state.Branch,
+				Branch:     state.Top,
+				UpdateRefs: true,
+			})
+			if err != nil {
+				return handleRebaseErr(git, theme, err,
+					fmt.Sprintf(`use "git stack restack" to finish editing %s`, state.Branch))
+			}
+			fmt.Printf("Rebased the branches above %s\n", state.Branch)
+		}
+
+		if err := git.Checkout(state.ReturnTo); err != nil {
+			return err
+		}
+		if err := git.UnsetEditState(); err != nil {
+			return err
+		}
+		fmt.Printf("Finished editing %s, returned to %s\n", state.Branch, state.ReturnTo)
+
+		if len(leftBehind) > 0 {
+			fmt.Println()
+			fmt.Printf("Stacks still based on the old commits of %s:\n", state.Branch)
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack rebase" from the top of each stack to move it)`)
+			for _, name := range leftBehind {
+				fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(name))
+			}
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currCommit, err := git.GetShortCommitHash("HEAD")
+		if err != nil {
+			return err
+		}
+		tree, err := stackparser.GetCurrentTree(stacks, currCommit)
+		if err != nil {
+			return err
+		}
+		printProblems(tree.Stacks, theme)
+		return nil
+	},
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+)
+
+var splitAtFlag []string
+var splitNameFlag []string
+
+func init() {
+	splitCmd.Flags().StringSliceVar(&splitAtFlag, "at", nil, "Commit(s) to split after, each becomes the tip of a new branch")
+	splitCmd.Flags().StringSliceVar(&splitNameFlag, "name", nil, "Name(s) of the new branches, ordered from the bottom of the stack")
+}
+
+var splitCmd = &cobra.Command{
+	Use:   "split [branch]",
+	Short: "Split a branch into multiple stacked branches",
+	Long: "Creates a new branch at each chosen commit of the branch, defaulting to the current branch. " +
+		"If the branch already has a change request, the new branches are pushed and change requests " +
+		"are opened for them with the correct target branches.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
+
+		var branch string
+		if len(args) == 1 {
+			branch = args[0]
+		} else {
+			branch, err = git.GetCurrentBranch()
+			if err != nil {
+				return err
+			}
+		}
+		stacks, _, err := parseStacks(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currStacks := getStacksWithBranch(stacks, branch)
+		if len(currStacks) == 0 {
+			return fmt.Errorf("%s is not a branch in any stack", branch)
+		}
+		s := currStacks[0]
+		branches, err := s.TotalOrderedBranches()
+		var errNoTotalOrder stackparser.NoTotalOrderError
+		if errors.As(err, &errNoTotalOrder) {
+			return fmt.Errorf("cannot split a branch in stack %s, it is partially ordered", s.Name)
+		} else if err != nil {
+			return err
+		}
+		parent := s.Base
+		if i := slices.Index(branches, branch); i < len(branches)-1 {
+			parent = branches[i+1]
+		}
+
+		log, err := git.Log(parent, branch)
+		if err != nil {
+			return err
+		}
+		// Ordered from oldest to newest, excluding the tip, which stays on the branch.
+		var candidates []libgit.Commit
+		for i := len(log.Commits) - 1; i > 0; i-- {
+			candidates = append(candidates, log.Commits[i])
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("%s only has one commit, nothing to split", branch)
+		}
+
+		var splitAt []string
+		if len(splitAtFlag) > 0 {
+			for _, at := range splitAtFlag {
+				hash, err := git.GetShortCommitHash(at)
+				if err != nil {
+					return err
+				}
+				if !slices.ContainsFunc(candidates, func(c libgit.Commit) bool { return c.Hash == hash }) {
+					return fmt.Errorf("cannot split at %s, it must be a commit of %s other than its tip", at, branch)
+				}
+				splitAt = append(splitAt, hash)
+			}
+		} else {
+			var opts []huh.Option[string]
+			for _, c := range candidates {
+				opts = append(opts, huh.NewOption(fmt.Sprintf("%s %s", c.Hash, c.Subject), c.Hash))
+			}
+			form := huh.NewForm(
+				huh.NewGroup(
+					huh.NewMultiSelect[string]().
+						Title(fmt.Sprintf("Choose the commits to split %s at (each becomes the tip of a new branch)", branch)).
+						Options(opts...).
+						Value(&splitAt),
+				),
+			)
+			if err := form.Run(); err != nil {
+				return err
+			}
+			if len(splitAt) == 0 {
+				fmt.Println("No commits chosen, nothing to split")
+				return nil
+			}
+		}
+		// Order the split points from the bottom of the stack.
+		slices.SortFunc(splitAt, func(a, b string) int {
+			return slices.IndexFunc(candidates, func(c libgit.Commit) bool { return c.Hash == a }) -
+				slices.IndexFunc(candidates, func(c libgit.Commit) bool { return c.Hash == b })
+		})
+		splitAt = slices.Compact(splitAt)
+
+		names := splitNameFlag
+		if len(names) > 0 && len(names) != len(splitAt) {
+			return fmt.Errorf("got %d names for %d new branches", len(names), len(splitAt))
+		}
+		if len(names) == 0 {
+			for i := range splitAt {
+				names = append(names, fmt.Sprintf("%s-%d", branch, i+1))
+			}
+			if len(splitAtFlag) == 0 {
+				var fields []huh.Field
+				for i, hash := range splitAt {
+					fields = append(fields, huh.NewInput().
+						Title(fmt.Sprintf("Name of the new branch at %s", hash)).
+						Value(&names[i]))
+				}
+				if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+					return err
+				}
+			}
+		}
+		hashes, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if _, ok := hashes[name]; ok {
+				return fmt.Errorf("a branch named %s already exists", name)
+			}
+		}
+
+		// Change requests are only opened for the new branches if the branch already has one.
+		pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+		hasPR := true
+		if errors.Is(err, githost.ErrDoesNotExist) {
+			hasPR = false
+		} else if err != nil {
+			return err
+		}
+
+		for i, hash := range splitAt {
+			if err := git.CreateBranch(names[i], hash); err != nil {
+				return err
+			}
+			fmt.Printf("Created branch %s at %s\n", names[i], hash)
+		}
+		if !hasPR {
+			fmt.Println()
+			return printBranches(deps, s.Name)
+		}
+
+		// Bottom to top: parent, new branches, then the split branch.
+		chain := append(append([]string{parent}, names...), branch)
+		wantTargets := map[string]string{}
+		for i := 1; i < len(chain); i++ {
+			wantTargets[chain[i]] = chain[i-1]
+		}
+		var prs []githost.PullRequest
+		var actionErr error
+		action := func() {
+			ctx := context.Background()
+			actionErr = concurrent.ForEach(ctx, names, func(ctx context.Context, name string) error {
+				_, err := git.Push(deps.pushRemote.Name, name, libgit.PushOpts{})
+				return err
+			})
+			if actionErr != nil {
+				return
+			}
+			prs, actionErr = concurrent.Map(ctx, names, func(ctx context.Context, name string) (githost.PullRequest, error) {
+				return host.CreateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+					Title:        name,
+					SourceBranch: name,
+					TargetBranch: wantTargets[name],
+				})
+			})
+			if actionErr != nil {
+				return
+			}
+			_, actionErr = host.UpdateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+				ID:           pr.ID,
+				Title:        pr.Title,
+				Description:  pr.Description,
+				SourceBranch: branch,
+				TargetBranch: wantTargets[branch],
+			})
+		}
+		vocab := host.GetVocabulary()
+		if err := runWithSpinner(fmt.Sprintf("Opening %s...", vocab.ChangeRequestNamePlural), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		fmt.Println()
+		fmt.Printf("Opened %s:\n", vocab.ChangeRequestNamePlural)
+		fmt.Println(strings.Repeat(" ", 2) + `(use "git stack push" to update the stack in their descriptions)`)
+		for _, pr := range prs {
+			fmt.Println(strings.Repeat(" ", 8) + fmt.Sprintf("%s -> %s (%s)", pr.SourceBranch, pr.TargetBranch, pr.WebURL))
+		}
+		return nil
+	},
+}
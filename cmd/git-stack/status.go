@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the push, review and CI status of each branch in the current stack",
+	Long: "For each branch in the current stack, shows how the branch compares to its remote branch as of the " +
+		"last fetch, and the state, target branch, CI status and approvals of its change request.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host, theme := deps.git, deps.repoCfg.DefaultBranch, deps.host, deps.theme
+		vocab := host.GetVocabulary()
+
+		tree, branches, err := getCurrentTree(git, defaultBranch)
+		if err != nil {
+			return err
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+		// Targets can't be checked without knowing which branch each branch is stacked on.
+		var wantTargets map[string]string
+		if parents, err := tree.Parents(); err == nil {
+			wantTargets = getTreeWantTargets(parents, tree.Base())
+		}
+
+		remotePrefix := fmt.Sprintf("refs/remotes/%s/", deps.pushRemote.Name)
+		remoteRefs, err := git.GetRefs(remotePrefix)
+		if err != nil {
+			return err
+		}
+		pushStates := map[string]string{}
+		for _, b := range branches {
+			remoteBranch := deps.pushRemote.Name + "/" + b
+			if !slices.Contains(remoteRefs, remotePrefix+b) {
+				pushStates[b] = theme.QuaternaryColor.Render("not pushed")
+				continue
+			}
+			ahead, behind, err := git.CountAheadBehind(b, remoteBranch)
+			if err != nil {
+				return err
+			}
+			switch {
+			case ahead > 0 && behind > 0:
+				pushStates[b] = theme.QuaternaryColor.Render(fmt.Sprintf(
+					"diverged from %s, %d and %d different commits", remoteBranch, ahead, behind))
+			case ahead > 0:
+				pushStates[b] = theme.QuaternaryColor.Render(fmt.Sprintf("%d ahead of %s", ahead, remoteBranch))
+			case behind > 0:
+				pushStates[b] = theme.QuaternaryColor.Render(fmt.Sprintf("%d behind %s", behind, remoteBranch))
+			default:
+				pushStates[b] = theme.SecondaryColor.Render("up to date with " + remoteBranch)
+			}
+		}
+
+		type branchStatus struct {
+			pr     githost.PullRequest
+			status githost.PullRequestStatus
+		}
+		var statuses []branchStatus
+		var actionErr error
+		action := func() {
+			statuses, actionErr = concurrent.Map(context.Background(), branches, func(ctx context.Context, branch string) (branchStatus, error) {
+				pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+				if errors.Is(err, githost.ErrDoesNotExist) {
+					pr, err = host.GetMergedChangeRequest(deps.remote.URLPath, branch)
+					if errors.Is(err, githost.ErrDoesNotExist) {
+						return branchStatus{}, nil
+					}
+					return branchStatus{pr: pr}, err
+				} else if err != nil {
+					return branchStatus{}, err
+				}
+				status, err := host.GetChangeRequestStatus(deps.remote.URLPath, pr)
+				return branchStatus{pr: pr, status: status}, err
+			})
+		}
+		if err := runWithSpinner(fmt.Sprintf("Fetching %s...", vocab.ChangeRequestNameShortPlural), action); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+
+		var retarget bool
+		for i, b := range branches {
+			if i > 0 {
+				fmt.Println()
+			}
+			hereMarker := " "
+			name := b
+			if b == currBranch {
+				hereMarker = "*"
+				name = theme.PrimaryColor.Render(b)
+			}
+			fmt.Printf("%s %s\n", hereMarker, name)
+			printStatusLine("Push", pushStates[b])
+
+			pr, status := statuses[i].pr, statuses[i].status
+			if pr.ID == 0 {
+				printStatusLine(vocab.ChangeRequestNameCapitalized, "none")
+				continue
+			}
+			printStatusLine(vocab.ChangeRequestNameCapitalized, fmt.Sprintf("%s %s", formatPRState(pr, theme), pr.WebURL))
+			if pr.State != githost.PullRequestStateOpen {
+				continue
+			}
+			if want, ok := wantTargets[b]; ok && want != pr.TargetBranch {
+				retarget = true
+				printStatusLine("Target", theme.QuaternaryColor.Render(
+					fmt.Sprintf("%s, but the branch is stacked on %s", pr.TargetBranch, want)))
+			} else {
+				printStatusLine("Target", pr.TargetBranch)
+			}
+			printStatusLine("CI", formatCIStatus(status.CI, theme))
+			printStatusLine("Reviews", formatReviews(status, theme))
+		}
+
+		if retarget {
+			fmt.Println()
+			fmt.Printf("some %s target the wrong branch (use \"git stack push\" to update)\n", vocab.ChangeRequestNamePlural)
+		}
+		return nil
+	},
+}
+
+func printStatusLine(label string, value string) {
+	fmt.Printf("    %-14s %s\n", label+":", value)
+}
+
+func formatPRState(pr githost.PullRequest, theme config.Theme) string {
+	switch {
+	case pr.State == githost.PullRequestStateMerged:
+		return theme.SecondaryColor.Render("merged")
+	case pr.State == githost.PullRequestStateClosed:
+		return theme.QuaternaryColor.Render("closed")
+	case pr.Draft:
+		return theme.TertiaryColor.Render("draft")
+	default:
+		return "open"
+	}
+}
+
+func formatCIStatus(ci githost.CIStatus, theme config.Theme) string {
+	switch ci {
+	case githost.CIStatusSuccess:
+		return theme.SecondaryColor.Render("passed")
+	case githost.CIStatusFailure:
+		return theme.QuaternaryColor.Render("failed")
+	case githost.CIStatusPending:
+		return theme.TertiaryColor.Render("running")
+	default:
+		return "none"
+	}
+}
+
+func formatReviews(status githost.PullRequestStatus, theme config.Theme) string {
+	approvals := fmt.Sprintf("%d approvals", status.Approvals)
+	if status.ApprovalsRequired > 0 {
+		approvals = fmt.Sprintf("%d of %d approvals", status.Approvals, status.ApprovalsRequired)
+		if status.Approvals >= status.ApprovalsRequired {
+			approvals = theme.SecondaryColor.Render(approvals)
+		}
+	}
+	parts := []string{approvals}
+	if status.ChangesRequested {
+		parts = append(parts, theme.QuaternaryColor.Render("changes requested"))
+	}
+	return strings.Join(parts, ", ")
+}
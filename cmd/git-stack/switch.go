@@ -39,11 +39,8 @@ var switchCmd = &cobra.Command{
 				return err
 			},
 			func(ctx context.Context) error {
-				log, err := git.LogAll(defaultBranch)
-				if err != nil {
-					return err
-				}
-				stacks, err = stackparser.ParseStacks(log)
+				var err error
+				stacks, _, err = parseStacks(git, defaultBranch)
 				return err
 			},
 		)
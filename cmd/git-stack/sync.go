@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/raymondji/git-stack-cli/concurrent"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Delete landed branches and restack the remaining branches",
+	Long: "Fetches origin, deletes branches whose change requests have been merged, " +
+		"rebases the remaining branches of each stack onto its base branch and retargets their change requests.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, defaultBranch, host, theme := deps.git, deps.repoCfg.DefaultBranch, deps.host, deps.theme
+		vocab := host.GetVocabulary()
+
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		currBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return err
+		}
+
+		var result landedBranches
+		var actionErr error
+		action := func() {
+			result, actionErr = getLandedBranches(deps)
+		}
+		if err := spinner.New().Title("Fetching landed branches...").Action(action).Run(); err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+		benchmarkPoint("syncCmd", "got landed branches")
+		stacks, landed := result.stacks, result.landed
+		for _, b := range result.localBases {
+			fmt.Printf("Warning: not updating base branch %s, it does not exist on %s\n", b, deps.remote.Name)
+		}
+		for _, b := range result.unmatched {
+			fmt.Printf("Warning: not treating %s as landed, it has commits that aren't in its merged %s\n", b, vocab.ChangeRequestName)
+		}
+
+		if err := snapshotBranches(git); err != nil {
+			return err
+		}
+
+		// Rebase the surviving branches before deleting anything, the landed branches
+		// are needed to know which commits to drop.
+		var restacked []string
+		wantTargets := map[string]string{}
+		// Landed branches in skipped stacks are kept, deleting them would leave the
+		// branches above them without a base to restack onto later.
+		kept := map[string]bool{}
+		keepLanded := func(branches []string) {
+			for _, b := range branches {
+				if landed[b] {
+					kept[b] = true
+				}
+			}
+		}
+		for _, s := range stacks {
+			branches, err := s.TotalOrderedBranches()
+			if err != nil {
+				fmt.Printf("Warning: skipping stack %s, it does not have a total order\n", s.Name)
+				keepLanded(s.Branches())
+				continue
+			}
+			landedIndex := slices.IndexFunc(branches, func(b string) bool {
+				return landed[b]
+			})
+			if landedIndex == -1 {
+				// Branches landed with a merge commit or fast-forward are no longer part of
+				// the stack, but the change requests that targeted them still need retargeting.
+				maps.Copy(wantTargets, getWantTargets(branches, s.Base))
+				continue
+			}
+			if slices.ContainsFunc(branches[landedIndex:], func(b string) bool {
+				return !landed[b]
+			}) {
+				fmt.Printf("Warning: skipping stack %s, its landed branches are not at the bottom of the stack\n", s.Name)
+				keepLanded(branches)
+				continue
+			}
+			if landedIndex == 0 {
+				// The entire stack landed.
+				continue
+			}
+			if len(s.DivergesFrom()) > 0 {
+				fmt.Printf("Warning: skipping stack %s, rebasing may lose the association between divergent stacks\n", s.Name)
+				keepLanded(branches)
+				continue
+			}
+			// Rebasing would only move the surviving branches for this stack, leaving behind
+			// any other stacks built on top of them.
+			sharesBranches := slices.ContainsFunc(stacks, func(other stackparser.Stack) bool {
+				return other.Name != s.Name && slices.ContainsFunc(branches[:landedIndex], func(b string) bool {
+					return slices.Contains(other.Branches(), b)
+				})
+			})
+			if sharesBranches {
+				fmt.Printf("Warning: skipping stack %s, rebasing may lose the association between stacks that share branches\n", s.Name)
+				keepLanded(branches)
+				continue
+			}
+
+			_, err = git.Rebase(branches[landedIndex], libgit.RebaseOpts{
+				Onto:       s.Base,
+				Branch:     branches[0],
+				UpdateRefs: true,
+			})
+			if err != nil {
+				return handleRebaseErr(git, theme, err,
+					`use "git stack sync" to restack the other stacks and delete the landed branches`,
+					`use "git stack push --safer-force" to push the restacked branches and update their change requests`)
+			}
+			restacked = append(restacked, fmt.Sprintf("%s onto %s", s.Name, s.Base))
+			maps.Copy(wantTargets, getWantTargets(branches[:landedIndex], s.Base))
+		}
+		benchmarkPoint("syncCmd", "restacked branches")
+
+		landedBranches := maps.Keys(landed)
+		slices.Sort(landedBranches)
+		if landed[currBranch] && !kept[currBranch] {
+			currBranch = defaultBranch
+		}
+		if err := git.Checkout(currBranch); err != nil {
+			return err
+		}
+		var deletedMsgs, keptBranches []string
+		for _, b := range landedBranches {
+			if kept[b] {
+				keptBranches = append(keptBranches, b)
+				continue
+			}
+			hash, err := git.GetShortCommitHash(b)
+			if err != nil {
+				return err
+			}
+			if err := git.DeleteBranchIfExists(b); err != nil {
+				return err
+			}
+			deletedMsgs = append(deletedMsgs, fmt.Sprintf("%s (was %s)", b, hash))
+		}
+		benchmarkPoint("syncCmd", "deleted landed branches")
+
+		action = func() {
+			actionErr = retargetChangeRequests(deps, wantTargets)
+		}
+		err = spinner.New().Title(fmt.Sprintf("Updating %s...", vocab.ChangeRequestNameShortPlural)).Action(action).Run()
+		if err != nil {
+			return err
+		}
+		if actionErr != nil {
+			return actionErr
+		}
+		benchmarkPoint("syncCmd", "retargeted change requests")
+
+		if len(deletedMsgs) == 0 && len(keptBranches) == 0 {
+			fmt.Println("No landed branches found.")
+			return nil
+		}
+		if len(deletedMsgs) > 0 {
+			fmt.Println("Deleted landed branches:")
+			for _, msg := range deletedMsgs {
+				fmt.Println(strings.Repeat(" ", 8) + theme.QuaternaryColor.Render(msg))
+			}
+		}
+		if len(keptBranches) > 0 {
+			if len(deletedMsgs) > 0 {
+				fmt.Println()
+			}
+			fmt.Println("Kept landed branches in skipped stacks:")
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack rebase" to move the branches above them, then "git branch -D <branch>")`)
+			for _, b := range keptBranches {
+				fmt.Println(strings.Repeat(" ", 8) + theme.TertiaryColor.Render(b))
+			}
+		}
+		if len(restacked) > 0 {
+			fmt.Println()
+			fmt.Println("Restacked stacks:")
+			fmt.Println(strings.Repeat(" ", 2) + `(use "git stack push --safer-force" to push the restacked branches)`)
+			for _, msg := range restacked {
+				fmt.Println(strings.Repeat(" ", 8) + theme.PrimaryColor.Render(msg))
+			}
+		}
+		return nil
+	},
+}
+
+type landedBranches struct {
+	stacks []stackparser.Stack
+	// Local branches whose change requests have been merged.
+	landed map[string]bool
+	// Branches with a merged change request that doesn't contain the branch's commits,
+	// e.g. if the branch name was reused after landing.
+	unmatched []string
+	// Base branches that only exist locally, these aren't updated.
+	localBases []string
+}
+
+// getLandedBranches fetches the latest base branches and returns the parsed stacks,
+// along with all local branches whose change requests have been merged.
+func getLandedBranches(deps deps) (landedBranches, error) {
+	git, defaultBranch, host := deps.git, deps.repoCfg.DefaultBranch, deps.host
+	if err := git.Fetch(deps.remote.Name); err != nil {
+		return landedBranches{}, err
+	}
+	bases, err := git.GetStackBases()
+	if err != nil {
+		return landedBranches{}, err
+	}
+	baseBranches := getBaseBranches(bases, defaultBranch)
+	remoteRefs, err := git.GetRefs("refs/remotes/" + deps.remote.Name + "/")
+	if err != nil {
+		return landedBranches{}, err
+	}
+	var localBases []string
+	for _, b := range baseBranches {
+		if !slices.Contains(remoteRefs, "refs/remotes/"+deps.remote.Name+"/"+b) {
+			localBases = append(localBases, b)
+			continue
+		}
+		if err := git.FastForward(b, deps.remote.Name+"/"+b); err != nil {
+			return landedBranches{}, err
+		}
+	}
+
+	var mergedBranches [][]string
+	var stacks []stackparser.Stack
+	err = concurrent.Run(
+		context.Background(),
+		func(ctx context.Context) error {
+			var err error
+			mergedBranches, err = concurrent.Map(ctx, baseBranches, func(ctx context.Context, base string) ([]string, error) {
+				return git.GetMergedBranches(base)
+			})
+			return err
+		},
+		func(ctx context.Context) error {
+			log, err := git.LogAll(baseBranches...)
+			if err != nil {
+				return err
+			}
+			stacks, err = stackparser.ParseStacks(log, stackparser.WithBases(defaultBranch, bases))
+			return err
+		},
+	)
+	if err != nil {
+		return landedBranches{}, err
+	}
+
+	// Branches merged with a merge commit or fast-forward are excluded from the stacks,
+	// while squash merged branches still show up in them.
+	candidates := map[string]struct{}{}
+	for _, merged := range mergedBranches {
+		for _, b := range merged {
+			if !slices.Contains(baseBranches, b) {
+				candidates[b] = struct{}{}
+			}
+		}
+	}
+	for _, s := range stacks {
+		for _, b := range s.Branches() {
+			candidates[b] = struct{}{}
+		}
+	}
+	// Only trust the host to decide if a branch landed, e.g. a freshly created
+	// branch without any commits is also merged into the default branch.
+	prs, err := concurrent.Map(context.Background(), maps.Keys(candidates), func(ctx context.Context, branch string) (githost.PullRequest, error) {
+		pr, err := host.GetMergedChangeRequest(deps.remote.URLPath, branch)
+		if errors.Is(err, githost.ErrDoesNotExist) {
+			return githost.PullRequest{}, nil
+		}
+		return pr, err
+	})
+	if err != nil {
+		return landedBranches{}, err
+	}
+
+	hashes, err := git.GetBranchHashes()
+	if err != nil {
+		return landedBranches{}, err
+	}
+	result := landedBranches{stacks: stacks, landed: map[string]bool{}, localBases: localBases}
+	for _, pr := range prs {
+		b := pr.SourceBranch
+		if b == "" {
+			continue
+		}
+		// The branch may have been pushed again after it landed, or the name reused for
+		// a new branch, so check that its commits actually landed with the change request.
+		contained := pr.HeadCommit == hashes[b]
+		if !contained && pr.HeadCommit != "" {
+			// Treat a head commit we can't look up as not containing the branch.
+			contained, _ = git.IsAncestor(hashes[b], pr.HeadCommit)
+		}
+		if !contained {
+			result.unmatched = append(result.unmatched, b)
+			continue
+		}
+		result.landed[b] = true
+	}
+	slices.Sort(result.unmatched)
+	return result, nil
+}
+
+// retargetChangeRequests updates the target branch of any existing change requests
+// that don't match wantTargets. Keys are source branches, values are target branches.
+func retargetChangeRequests(deps deps, wantTargets map[string]string) error {
+	host := deps.host
+	return concurrent.ForEach(context.Background(), maps.Keys(wantTargets), func(ctx context.Context, branch string) error {
+		pr, err := host.GetChangeReqeuest(deps.remote.URLPath, branch)
+		if errors.Is(err, githost.ErrDoesNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if pr.TargetBranch == wantTargets[branch] {
+			return nil
+		}
+
+		_, err = host.UpdateChangeRequest(deps.remote.URLPath, githost.PullRequest{
+			ID:           pr.ID,
+			Title:        pr.Title,
+			Description:  pr.Description,
+			SourceBranch: branch,
+			TargetBranch: wantTargets[branch],
+		})
+		return err
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var undoListFlag bool
+
+func init() {
+	undoCmd.Flags().BoolVarP(&undoListFlag, "list", "l", false, "List the snapshots that can be restored")
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [snapshot]",
+	Short: "Undo the last command that modified branches",
+	Long: "Commands that rewrite or delete branches first record the tip of every local branch in a snapshot " +
+		"(under refs/git-stack/snapshots/). Undo restores the branches from the most recent snapshot, " +
+		"or from a specific snapshot listed by --list.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, err := initDeps()
+		if err != nil {
+			return err
+		}
+		git, theme := deps.git, deps.theme
+
+		snapshots, err := snapshot.List(git)
+		if err != nil {
+			return err
+		}
+		if undoListFlag {
+			if len(snapshots) == 0 {
+				fmt.Println("No snapshots")
+				return nil
+			}
+			for _, s := range snapshots {
+				desc := s.Command
+				if s.Undoes != "" {
+					desc = fmt.Sprintf("%s (undid %s)", desc, s.Undoes)
+				}
+				fmt.Printf("%s %s %s\n",
+					theme.TertiaryColor.Render(s.ID),
+					s.Time.Local().Format("2006-01-02 15:04:05"),
+					desc)
+			}
+			return nil
+		}
+
+		var target snapshot.Snapshot
+		if len(args) == 1 {
+			var found bool
+			for _, s := range snapshots {
+				if s.ID == args[0] {
+					target = s
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("no snapshot with id: %s", args[0])
+			}
+		} else {
+			var ok bool
+			target, ok = snapshot.LatestUndoable(snapshots)
+			if !ok {
+				fmt.Println("Nothing to undo")
+				return nil
+			}
+		}
+
+		if ok, err := git.IsRepoClean(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("aborting, git repo has changes")
+		}
+		current, err := git.GetBranchHashes()
+		if err != nil {
+			return err
+		}
+		changed, err := snapshot.Restore(git, target, commandLine())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored branches to before %q\n", target.Command)
+		if len(changed) == 0 {
+			fmt.Println(strings.Repeat(" ", 2) + "(no branches changed)")
+		}
+		for _, b := range changed {
+			from := "deleted"
+			if hash, ok := current[b]; ok {
+				from = shortHash(hash)
+			}
+			fmt.Printf("%s%s: %s -> %s\n", strings.Repeat(" ", 8), b, from, shortHash(target.Branches[b]))
+		}
+
+		var created []string
+		for b := range current {
+			if _, ok := target.Branches[b]; !ok {
+				created = append(created, b)
+			}
+		}
+		if len(created) > 0 {
+			slices.Sort(created)
+			fmt.Println()
+			fmt.Println("Branches created since the snapshot were left unchanged:")
+			for _, b := range created {
+				fmt.Printf("%s%s\n", strings.Repeat(" ", 8), b)
+			}
+		}
+		return nil
+	},
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -13,7 +13,7 @@ import (
 type Config struct {
 	Theme ThemeConfig `json:"theme"`
 
-	// Keys are the git repo path, e.g. "raymondji/git-stack-cli"
+	// Keys are the git repo path of the origin remote, e.g. "raymondji/git-stack-cli"
 	Repositories map[string]RepoConfig `json:"repositories"`
 }
 
@@ -25,9 +25,23 @@ type ThemeConfig struct {
 }
 
 type RepoConfig struct {
-	DefaultBranch string       `json:"defaultBranch"`
-	Gitlab        GitlabConfig `json:"gitlab"`
-	Github        GithubConfig `json:"github"`
+	DefaultBranch string `json:"defaultBranch"`
+	// One of "merge", "squash" or "rebase". Defaults to "merge". GitLab doesn't support "rebase",
+	// fast-forward merges are configured in the project settings instead.
+	MergeMethod string `json:"mergeMethod,omitempty"`
+	// One of "GITHUB" or "GITLAB". Only needed if it can't be detected from the remote url,
+	// e.g. for self-hosted instances.
+	HostKind string `json:"hostKind,omitempty"`
+	// e.g. https://github.example.com/api/v3/ or https://gitlab.example.com/api/v4.
+	// Defaults to the API for github.com or gitlab.com.
+	APIBaseURL string `json:"apiBaseURL,omitempty"`
+	// The remote that branches are pushed to, e.g. a fork. Defaults to "origin".
+	PushRemote string `json:"pushRemote,omitempty"`
+	// The remote that change requests are opened against, e.g. "upstream" when pushing
+	// to a fork. Defaults to "origin".
+	TargetRemote string       `json:"targetRemote,omitempty"`
+	Gitlab       GitlabConfig `json:"gitlab"`
+	Github       GithubConfig `json:"github"`
 }
 
 type GitlabConfig struct {
@@ -35,6 +35,8 @@ type runOpts struct {
 	// interact with the user. Stdout and Stderr will be empty.
 	Interactive bool
 	OSStdout    bool
+	// Written to the command's stdin, unless Interactive is set.
+	Stdin string
 }
 
 type runOpt func(*runOpts)
@@ -63,6 +65,18 @@ func WithDir(dir string) runOpt {
 	}
 }
 
+func WithIgnoreExitError() runOpt {
+	return func(opts *runOpts) {
+		opts.IgnoreExitError = true
+	}
+}
+
+func WithStdin(stdin string) runOpt {
+	return func(opts *runOpts) {
+		opts.Stdin = stdin
+	}
+}
+
 func WithOSStdout() runOpt {
 	return func(opts *runOpts) {
 		opts.OSStdout = true
@@ -89,6 +103,9 @@ func Run(name string, fOpts ...runOpt) (*Output, error) {
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 	}
+	if !opts.Interactive && opts.Stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.Stdin)
+	}
 	cmd.Env = append(os.Environ(), opts.Env...)
 	err := cmd.Run()
 	var exitError *exec.ExitError
@@ -2,6 +2,10 @@ package githost
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/raymondji/git-stack-cli/config"
 	"github.com/raymondji/git-stack-cli/githost/github"
@@ -10,16 +14,42 @@ import (
 )
 
 type (
-	Host        = internal.Host
-	PullRequest = internal.ChangeRequest
-	Repo        = internal.Repo
-	Vocabulary  = internal.Vocabulary
+	Host              = internal.Host
+	Opts              = internal.Opts
+	PullRequest       = internal.ChangeRequest
+	PullRequestState  = internal.ChangeRequestState
+	PullRequestStatus = internal.ChangeRequestStatus
+	CIStatus          = internal.CIStatus
+	Check             = internal.Check
+	MergeMethod       = internal.MergeMethod
+	Repo              = internal.Repo
+	Vocabulary        = internal.Vocabulary
 )
 
 var (
 	ErrDoesNotExist = internal.ErrDoesNotExist
 )
 
+const (
+	PullRequestStateOpen   = internal.ChangeRequestStateOpen
+	PullRequestStateMerged = internal.ChangeRequestStateMerged
+	PullRequestStateClosed = internal.ChangeRequestStateClosed
+
+	CIStatusNone    = internal.CIStatusNone
+	CIStatusPending = internal.CIStatusPending
+	CIStatusSuccess = internal.CIStatusSuccess
+	CIStatusFailure = internal.CIStatusFailure
+
+	MergeMethodMerge  = internal.MergeMethodMerge
+	MergeMethodSquash = internal.MergeMethodSquash
+	MergeMethodRebase = internal.MergeMethodRebase
+)
+
+// CombineCIStatuses returns the worst of the given statuses: any failure fails, then any pending is pending.
+func CombineCIStatuses(statuses []CIStatus) CIStatus {
+	return internal.CombineCIStatuses(statuses)
+}
+
 type Kind string
 
 const (
@@ -27,22 +57,74 @@ const (
 	Github Kind = "GITHUB"
 )
 
-func New(kind Kind, repoCfg config.RepoConfig) (Host, error) {
+// New creates a client for the given kind of host. The host kind and API base URL
+// configured in repoCfg take precedence, e.g. for self-hosted instances.
+// sourceRepoPath is only needed if branches are pushed to a fork, see Opts.
+func New(kind Kind, repoCfg config.RepoConfig, sourceRepoPath string) (Host, error) {
+	opts := Opts{
+		BaseURL:        repoCfg.APIBaseURL,
+		SourceRepoPath: sourceRepoPath,
+	}
+	if repoCfg.HostKind != "" {
+		kind = Kind(repoCfg.HostKind)
+	}
+
 	switch kind {
 	case Gitlab:
-		host, err := gitlab.New(repoCfg.Gitlab.PersonalAccessToken)
+		host, err := gitlab.New(repoCfg.Gitlab.PersonalAccessToken, opts)
 		if err != nil {
 			return host, fmt.Errorf("failed to init gitlab client, err: %v", err)
 		}
 		return host, nil
 	case Github:
-		host, err := github.New(repoCfg.Github.PersonalAccessToken)
+		host, err := github.New(repoCfg.Github.PersonalAccessToken, opts)
 		if err != nil {
 			return host, fmt.Errorf("failed to init github client, err: %v", err)
 		}
 		return host, nil
+	case "":
+		var host Host
+		return host, fmt.Errorf("unknown git host, please setup git stack using the `git stack init` command")
 	default:
 		var host Host
 		return host, fmt.Errorf("unsupported git host %s", kind)
 	}
 }
+
+// DefaultAPIBaseURL returns the usual API base URL for a self-hosted instance of the given
+// kind of host, or an empty string for github.com and gitlab.com.
+func DefaultAPIBaseURL(kind Kind, hostname string) string {
+	if hostname == "github.com" || hostname == "gitlab.com" {
+		return ""
+	}
+	switch kind {
+	case Gitlab:
+		return fmt.Sprintf("https://%s/api/v4", hostname)
+	case Github:
+		return fmt.Sprintf("https://%s/api/v3/", hostname)
+	default:
+		return ""
+	}
+}
+
+var changeRequestURLRegex = regexp.MustCompile(`^/(.+?)(?:/-)?/(?:pull|merge_requests)/(\d+)(?:/.*)?$`)
+
+// ParseChangeRequestRef parses a change request number, optionally prefixed with # or !,
+// or a change request URL, e.g. https://github.com/owner/repo/pull/12. For URLs, the path
+// of the repo that the change request belongs to is returned too, e.g. owner/repo.
+func ParseChangeRequestRef(s string) (string, int, error) {
+	var repoPath string
+	raw := strings.TrimLeft(s, "#!")
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		m := changeRequestURLRegex.FindStringSubmatch(u.Path)
+		if m == nil {
+			return "", 0, fmt.Errorf("invalid change request URL: %s", s)
+		}
+		repoPath, raw = m[1], m[2]
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("invalid change request number or URL: %s", s)
+	}
+	return repoPath, id, nil
+}
@@ -0,0 +1,347 @@
+package githost_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raymondji/git-stack-cli/config"
+	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSelfHosted(t *testing.T) {
+	cases := map[string]struct {
+		kind    githost.Kind
+		repoCfg config.RepoConfig
+		// Path that the fake server responds to
+		wantPath string
+		response string
+	}{
+		"github enterprise": {
+			repoCfg: config.RepoConfig{
+				HostKind: string(githost.Github),
+			},
+			wantPath: "/api/v3/repos/raymondji/git-stack-cli",
+			response: `{"default_branch": "trunk"}`,
+		},
+		"gitlab self-hosted": {
+			repoCfg: config.RepoConfig{
+				HostKind: string(githost.Gitlab),
+			},
+			wantPath: "/api/v4/projects/raymondji/git-stack-cli",
+			response: `{"default_branch": "trunk"}`,
+		},
+		"detected kind": {
+			kind:     githost.Github,
+			wantPath: "/api/v3/repos/raymondji/git-stack-cli",
+			response: `{"default_branch": "trunk"}`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var gotPaths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPaths = append(gotPaths, r.URL.Path)
+				if r.URL.Path != c.wantPath {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(c.response))
+			}))
+			defer server.Close()
+
+			c.repoCfg.APIBaseURL = server.URL
+			host, err := githost.New(c.kind, c.repoCfg, "")
+			require.NoError(t, err)
+
+			repo, err := host.GetRepo("raymondji/git-stack-cli")
+			require.NoError(t, err, "requested paths: %v", gotPaths)
+			require.Equal(t, "trunk", repo.DefaultBranch)
+		})
+	}
+}
+
+func TestDefaultAPIBaseURL(t *testing.T) {
+	require.Equal(t, "", githost.DefaultAPIBaseURL(githost.Github, "github.com"))
+	require.Equal(t, "", githost.DefaultAPIBaseURL(githost.Gitlab, "gitlab.com"))
+	require.Equal(t, "https://github.example.com/api/v3/", githost.DefaultAPIBaseURL(githost.Github, "github.example.com"))
+	require.Equal(t, "https://git.corp.example/api/v4", githost.DefaultAPIBaseURL(githost.Gitlab, "git.corp.example"))
+}
+
+func TestGithubFork(t *testing.T) {
+	var gotHead string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v3/repos/upstream/repo/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Head string `json:"head"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gotHead = body.Head
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"number": 1,
+			"title": "feature",
+			"html_url": "https://github.example.com/upstream/repo/pull/1",
+			"head": {"ref": "feature"},
+			"base": {"ref": "main"}
+		}`))
+	}))
+	defer server.Close()
+
+	host, err := githost.New(githost.Github, config.RepoConfig{APIBaseURL: server.URL}, "fork/repo")
+	require.NoError(t, err)
+
+	pr, err := host.CreateChangeRequest("upstream/repo", githost.PullRequest{
+		Title:        "feature",
+		SourceBranch: "feature",
+		TargetBranch: "main",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fork:feature", gotHead)
+	require.Equal(t, "feature", pr.SourceBranch)
+}
+
+func TestChangeRequestFromFork(t *testing.T) {
+	cases := map[string]struct {
+		repoCfg  config.RepoConfig
+		path     string
+		response string
+		want     bool
+	}{
+		"github same repo": {
+			repoCfg:  config.RepoConfig{HostKind: string(githost.Github)},
+			path:     "/api/v3/repos/owner/repo/pulls/1",
+			response: `{"number": 1, "title": "t", "html_url": "u", "head": {"ref": "feature", "repo": {"id": 1}}, "base": {"ref": "main", "repo": {"id": 1}}}`,
+			want:     false,
+		},
+		"github fork": {
+			repoCfg:  config.RepoConfig{HostKind: string(githost.Github)},
+			path:     "/api/v3/repos/owner/repo/pulls/1",
+			response: `{"number": 1, "title": "t", "html_url": "u", "head": {"ref": "feature", "repo": {"id": 2}}, "base": {"ref": "main", "repo": {"id": 1}}}`,
+			want:     true,
+		},
+		"gitlab same project": {
+			repoCfg:  config.RepoConfig{HostKind: string(githost.Gitlab)},
+			path:     "/api/v4/projects/owner/repo/merge_requests/1",
+			response: `{"iid": 1, "source_branch": "feature", "target_branch": "main", "source_project_id": 1, "target_project_id": 1}`,
+			want:     false,
+		},
+		"gitlab fork": {
+			repoCfg:  config.RepoConfig{HostKind: string(githost.Gitlab)},
+			path:     "/api/v4/projects/owner/repo/merge_requests/1",
+			response: `{"iid": 1, "source_branch": "feature", "target_branch": "main", "source_project_id": 2, "target_project_id": 1}`,
+			want:     true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != c.path {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(c.response))
+			}))
+			defer server.Close()
+
+			c.repoCfg.APIBaseURL = server.URL
+			host, err := githost.New("", c.repoCfg, "")
+			require.NoError(t, err)
+
+			pr, err := host.GetChangeRequestByID("owner/repo", 1)
+			require.NoError(t, err)
+			require.Equal(t, c.want, pr.FromFork)
+		})
+	}
+}
+
+func TestGetChangeRequestStatus(t *testing.T) {
+	cases := map[string]struct {
+		repoCfg config.RepoConfig
+		// Responses by path
+		responses map[string]string
+		want      githost.PullRequestStatus
+	}{
+		"github": {
+			repoCfg: config.RepoConfig{HostKind: string(githost.Github)},
+			responses: map[string]string{
+				"/api/v3/repos/owner/repo/commits/abc123/check-runs": `{"total_count": 2, "check_runs": [
+					{"status": "completed", "conclusion": "success"},
+					{"status": "in_progress"}
+				]}`,
+				"/api/v3/repos/owner/repo/commits/abc123/status": `{"state": "success", "total_count": 1, "statuses": [
+					{"context": "ci/jenkins", "state": "success"}
+				]}`,
+				"/api/v3/repos/owner/repo/pulls/1/reviews": `[
+					{"user": {"login": "a"}, "state": "CHANGES_REQUESTED"},
+					{"user": {"login": "a"}, "state": "APPROVED"},
+					{"user": {"login": "a"}, "state": "COMMENTED"},
+					{"user": {"login": "b"}, "state": "CHANGES_REQUESTED"}
+				]`,
+			},
+			want: githost.PullRequestStatus{
+				CI:               githost.CIStatusPending,
+				Approvals:        1,
+				ChangesRequested: true,
+			},
+		},
+		"gitlab": {
+			repoCfg: config.RepoConfig{HostKind: string(githost.Gitlab)},
+			responses: map[string]string{
+				"/api/v4/projects/owner/repo/merge_requests/1/pipelines": `[{"id": 2, "project_id": 7, "status": "failed"}, {"id": 1, "project_id": 7, "status": "success"}]`,
+				"/api/v4/projects/7/pipelines/2/jobs": `[
+					{"name": "test", "status": "failed"},
+					{"name": "flaky", "status": "failed", "allow_failure": true},
+					{"name": "lint", "status": "success"}
+				]`,
+				"/api/v4/projects/owner/repo/merge_requests/1/approvals": `{"approvals_required": 2, "approved_by": [{"user": {"username": "a"}}]}`,
+			},
+			want: githost.PullRequestStatus{
+				CI:                githost.CIStatusFailure,
+				Approvals:         1,
+				ApprovalsRequired: 2,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response, ok := c.responses[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			c.repoCfg.APIBaseURL = server.URL
+			host, err := githost.New("", c.repoCfg, "")
+			require.NoError(t, err)
+
+			status, err := host.GetChangeRequestStatus("owner/repo", githost.PullRequest{ID: 1, HeadCommit: "abc123"})
+			require.NoError(t, err)
+			require.Equal(t, c.want, status)
+		})
+	}
+}
+
+func TestGetChecks(t *testing.T) {
+	cases := map[string]struct {
+		repoCfg config.RepoConfig
+		// Responses by path
+		responses map[string]string
+		want      []githost.Check
+	}{
+		"github": {
+			repoCfg: config.RepoConfig{HostKind: string(githost.Github)},
+			responses: map[string]string{
+				"/api/v3/repos/owner/repo/commits/abc123/check-runs": `{"total_count": 1, "check_runs": [
+					{"name": "lint", "status": "completed", "conclusion": "failure", "html_url": "https://github.com/runs/1"}
+				]}`,
+				"/api/v3/repos/owner/repo/commits/abc123/status": `{"state": "pending", "total_count": 1, "statuses": [
+					{"context": "ci/jenkins", "state": "pending", "target_url": "https://jenkins.example.com/1"}
+				]}`,
+			},
+			want: []githost.Check{
+				{Name: "lint", Status: githost.CIStatusFailure, WebURL: "https://github.com/runs/1"},
+				{Name: "ci/jenkins", Status: githost.CIStatusPending, WebURL: "https://jenkins.example.com/1"},
+			},
+		},
+		"gitlab": {
+			repoCfg: config.RepoConfig{HostKind: string(githost.Gitlab)},
+			responses: map[string]string{
+				"/api/v4/projects/owner/repo/merge_requests/1/pipelines": `[{"id": 2, "project_id": 7, "status": "failed"}]`,
+				"/api/v4/projects/7/pipelines/2/jobs": `[
+					{"name": "test", "status": "failed", "web_url": "https://gitlab.com/jobs/1"},
+					{"name": "flaky", "status": "failed", "allow_failure": true, "web_url": "https://gitlab.com/jobs/2"},
+					{"name": "deploy", "status": "manual", "web_url": "https://gitlab.com/jobs/3"}
+				]`,
+			},
+			want: []githost.Check{
+				{Name: "test", Status: githost.CIStatusFailure, WebURL: "https://gitlab.com/jobs/1"},
+				{Name: "flaky", Status: githost.CIStatusSuccess, WebURL: "https://gitlab.com/jobs/2"},
+				{Name: "deploy", Status: githost.CIStatusNone, WebURL: "https://gitlab.com/jobs/3"},
+			},
+		},
+		"gitlab no pipelines": {
+			repoCfg: config.RepoConfig{HostKind: string(githost.Gitlab)},
+			responses: map[string]string{
+				"/api/v4/projects/owner/repo/merge_requests/1/pipelines": `[]`,
+			},
+			want: []githost.Check{},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response, ok := c.responses[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			c.repoCfg.APIBaseURL = server.URL
+			host, err := githost.New("", c.repoCfg, "")
+			require.NoError(t, err)
+
+			checks, err := host.GetChecks("owner/repo", githost.PullRequest{ID: 1, HeadCommit: "abc123"})
+			require.NoError(t, err)
+			require.Equal(t, c.want, checks)
+		})
+	}
+}
+
+func TestCombineCIStatuses(t *testing.T) {
+	require.Equal(t, githost.CIStatusNone, githost.CombineCIStatuses(nil))
+	require.Equal(t, githost.CIStatusNone, githost.CombineCIStatuses([]githost.CIStatus{githost.CIStatusNone}))
+	require.Equal(t, githost.CIStatusSuccess, githost.CombineCIStatuses([]githost.CIStatus{githost.CIStatusNone, githost.CIStatusSuccess}))
+	require.Equal(t, githost.CIStatusPending, githost.CombineCIStatuses([]githost.CIStatus{githost.CIStatusSuccess, githost.CIStatusPending}))
+	require.Equal(t, githost.CIStatusFailure, githost.CombineCIStatuses([]githost.CIStatus{githost.CIStatusPending, githost.CIStatusFailure}))
+}
+
+func TestParseChangeRequestRef(t *testing.T) {
+	cases := map[string]struct {
+		repoPath string
+		id       int
+	}{
+		"12":                                    {id: 12},
+		"#12":                                   {id: 12},
+		"!12":                                   {id: 12},
+		"https://github.com/owner/repo/pull/12": {repoPath: "owner/repo", id: 12},
+		"https://github.com/owner/repo/pull/12/files":                {repoPath: "owner/repo", id: 12},
+		"https://gitlab.com/group/sub/repo/-/merge_requests/34":      {repoPath: "group/sub/repo", id: 34},
+		"https://gitlab.com/group/repo/-/merge_requests/34#note_1":   {repoPath: "group/repo", id: 34},
+		"https://github.example.com/owner/repo/pull/56?diff=unified": {repoPath: "owner/repo", id: 56},
+	}
+	for input, want := range cases {
+		repoPath, id, err := githost.ParseChangeRequestRef(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want.repoPath, repoPath, input)
+		require.Equal(t, want.id, id, input)
+	}
+
+	for _, input := range []string{"", "abc", "0", "https://github.com/owner/repo/issues/12"} {
+		_, _, err := githost.ParseChangeRequestRef(input)
+		require.Error(t, err, input)
+	}
+}
@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/google/go-github/v68/github"
@@ -10,13 +11,24 @@ import (
 )
 
 type githubClient struct {
-	client *github.Client
+	client         *github.Client
+	sourceRepoPath string
 }
 
-func New(personalAccessToken string) (internal.Host, error) {
+// New creates a client for github.com, or for a GitHub Enterprise Server instance if
+// opts.BaseURL is set, e.g. https://github.example.com/api/v3/.
+func New(personalAccessToken string, opts internal.Opts) (internal.Host, error) {
 	client := github.NewClient(nil).WithAuthToken(personalAccessToken)
+	if opts.BaseURL != "" {
+		var err error
+		client, err = client.WithEnterpriseURLs(opts.BaseURL, opts.BaseURL)
+		if err != nil {
+			return &githubClient{}, fmt.Errorf("invalid base url %s, err: %v", opts.BaseURL, err)
+		}
+	}
 	return &githubClient{
-		client: client,
+		client:         client,
+		sourceRepoPath: opts.SourceRepoPath,
 	}, nil
 }
 
@@ -53,9 +65,13 @@ func (g *githubClient) GetChangeReqeuest(repoPath string, sourceBranch string) (
 		return internal.ChangeRequest{}, err
 	}
 
+	head, err := g.getHead(owner, sourceBranch)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
 	opts := &github.PullRequestListOptions{
 		State: "open",
-		Head:  fmt.Sprintf("%s:%s", owner, sourceBranch),
+		Head:  head,
 	}
 	prs, _, err := g.client.PullRequests.List(context.Background(), owner, repo, opts)
 	if err != nil {
@@ -76,6 +92,131 @@ func (g *githubClient) GetChangeReqeuest(repoPath string, sourceBranch string) (
 	}
 }
 
+func (g *githubClient) GetMergedChangeRequest(repoPath string, sourceBranch string) (internal.ChangeRequest, error) {
+	owner, repo, err := parseRepoPath(repoPath)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+
+	head, err := g.getHead(owner, sourceBranch)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+	opts := &github.PullRequestListOptions{
+		State:     "closed",
+		Head:      head,
+		Sort:      "updated",
+		Direction: "desc",
+	}
+	prs, _, err := g.client.PullRequests.List(context.Background(), owner, repo, opts)
+	if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	for _, pr := range prs {
+		// Closed pull requests may or may not have been merged.
+		if pr.MergedAt != nil {
+			return convertPR(pr), nil
+		}
+	}
+	return internal.ChangeRequest{}, fmt.Errorf("%w, source branch: %s", internal.ErrDoesNotExist, sourceBranch)
+}
+
+func (g *githubClient) GetChangeRequestByID(repoPath string, id int) (internal.ChangeRequest, error) {
+	owner, repo, err := parseRepoPath(repoPath)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+
+	pr, resp, err := g.client.PullRequests.Get(context.Background(), owner, repo, id)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return internal.ChangeRequest{}, fmt.Errorf("%w, id: %d", internal.ErrDoesNotExist, id)
+	} else if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return convertPR(pr), nil
+}
+
+// GetChangeRequestStatus combines the checks on the pull request's head commit, and counts the
+// reviewers whose latest review approves it.
+func (g *githubClient) GetChangeRequestStatus(repoPath string, pr internal.ChangeRequest) (internal.ChangeRequestStatus, error) {
+	owner, repo, err := parseRepoPath(repoPath)
+	if err != nil {
+		return internal.ChangeRequestStatus{}, err
+	}
+	checks, err := g.GetChecks(repoPath, pr)
+	if err != nil {
+		return internal.ChangeRequestStatus{}, err
+	}
+	var states []internal.CIStatus
+	for _, c := range checks {
+		states = append(states, c.Status)
+	}
+
+	reviews, _, err := g.client.PullRequests.ListReviews(context.Background(), owner, repo, pr.ID, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return internal.ChangeRequestStatus{}, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	// Reviews are listed in chronological order, only the latest one from each reviewer counts.
+	latest := map[string]string{}
+	for _, r := range reviews {
+		if r.GetState() == "COMMENTED" {
+			continue
+		}
+		latest[r.GetUser().GetLogin()] = r.GetState()
+	}
+	status := internal.ChangeRequestStatus{
+		CI: internal.CombineCIStatuses(states),
+	}
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			status.Approvals++
+		case "CHANGES_REQUESTED":
+			status.ChangesRequested = true
+		}
+	}
+	return status, nil
+}
+
+// GetChecks returns both the check runs, e.g. from GitHub Actions, and the commit statuses, e.g. from
+// external CI services, on the pull request's head commit.
+func (g *githubClient) GetChecks(repoPath string, pr internal.ChangeRequest) ([]internal.Check, error) {
+	owner, repo, err := parseRepoPath(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	checkRuns, _, err := g.client.Checks.ListCheckRunsForRef(ctx, owner, repo, pr.HeadCommit, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check runs: %w", err)
+	}
+	combined, _, err := g.client.Repositories.GetCombinedStatus(ctx, owner, repo, pr.HeadCommit, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %w", err)
+	}
+
+	checks := []internal.Check{}
+	for _, run := range checkRuns.CheckRuns {
+		checks = append(checks, internal.Check{
+			Name:   run.GetName(),
+			Status: convertCheckRun(run),
+			WebURL: run.GetHTMLURL(),
+		})
+	}
+	for _, status := range combined.Statuses {
+		checks = append(checks, internal.Check{
+			Name:   status.GetContext(),
+			Status: convertCommitState(status.GetState()),
+			WebURL: status.GetTargetURL(),
+		})
+	}
+	return checks, nil
+}
+
 func (g *githubClient) CreateChangeRequest(repoPath string, pr internal.ChangeRequest) (internal.ChangeRequest, error) {
 	if pr.Title == "" {
 		return internal.ChangeRequest{}, fmt.Errorf("pull request title cannot be empty")
@@ -86,10 +227,15 @@ func (g *githubClient) CreateChangeRequest(repoPath string, pr internal.ChangeRe
 		return internal.ChangeRequest{}, err
 	}
 
+	head, err := g.getHead(owner, pr.SourceBranch)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+
 	// TODO: add optional support for draft PRs, not supported in every repo
 	newPR := &github.NewPullRequest{
 		Title: github.Ptr(pr.Title),
-		Head:  github.Ptr(pr.SourceBranch),
+		Head:  github.Ptr(head),
 		Base:  github.Ptr(pr.TargetBranch),
 		Body:  github.Ptr(pr.Description),
 	}
@@ -157,6 +303,26 @@ func (g githubClient) CloseChangeRequest(repoPath string, pr internal.ChangeRequ
 	return convertPR(prResult), nil
 }
 
+func (g *githubClient) MergeChangeRequest(repoPath string, pr internal.ChangeRequest, method internal.MergeMethod) (internal.ChangeRequest, error) {
+	if pr.ID == 0 {
+		return internal.ChangeRequest{}, fmt.Errorf("pull request ID must be set")
+	}
+
+	owner, repo, err := parseRepoPath(repoPath)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+
+	_, _, err = g.client.PullRequests.Merge(context.Background(), owner, repo, pr.ID, "", &github.PullRequestOptions{
+		MergeMethod: string(method),
+	})
+	if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to merge pull request, pr: %+v, err: %w", pr, err)
+	}
+
+	return g.GetChangeRequestByID(repoPath, pr.ID)
+}
+
 func convertPR(pr *github.PullRequest) internal.ChangeRequest {
 	out := internal.ChangeRequest{
 		ID:             *pr.Number,
@@ -169,9 +335,56 @@ func convertPR(pr *github.PullRequest) internal.ChangeRequest {
 	if pr.Body != nil {
 		out.Description = *pr.Body
 	}
+	out.Draft = pr.GetDraft()
+	out.HeadCommit = pr.GetHead().GetSHA()
+	out.FromFork = pr.GetHead().GetRepo().GetID() != pr.GetBase().GetRepo().GetID()
+	switch {
+	case pr.MergedAt != nil:
+		out.State = internal.ChangeRequestStateMerged
+	case pr.GetState() == "closed":
+		out.State = internal.ChangeRequestStateClosed
+	default:
+		out.State = internal.ChangeRequestStateOpen
+	}
 	return out
 }
 
+func convertCheckRun(run *github.CheckRun) internal.CIStatus {
+	if run.GetStatus() != "completed" {
+		return internal.CIStatusPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return internal.CIStatusSuccess
+	default:
+		return internal.CIStatusFailure
+	}
+}
+
+func convertCommitState(state string) internal.CIStatus {
+	switch state {
+	case "success":
+		return internal.CIStatusSuccess
+	case "pending":
+		return internal.CIStatusPending
+	default:
+		return internal.CIStatusFailure
+	}
+}
+
+// getHead returns the head of a pull request from sourceBranch in the form owner:branch,
+// where owner is the owner of the fork if opening pull requests from a fork.
+func (g *githubClient) getHead(owner string, sourceBranch string) (string, error) {
+	if g.sourceRepoPath != "" {
+		sourceOwner, _, err := parseRepoPath(g.sourceRepoPath)
+		if err != nil {
+			return "", err
+		}
+		owner = sourceOwner
+	}
+	return fmt.Sprintf("%s:%s", owner, sourceBranch), nil
+}
+
 // parseRepoPath returns (owner, name, error)
 func parseRepoPath(repoPath string) (string, string, error) {
 	parts := strings.Split(repoPath, "/")
@@ -2,22 +2,31 @@ package gitlab
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/raymondji/git-stack-cli/githost/internal"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 type gitlabClient struct {
-	client *gitlab.Client
+	client         *gitlab.Client
+	sourceRepoPath string
 }
 
-func New(personalAccessToken string) (internal.Host, error) {
-	client, err := gitlab.NewClient(personalAccessToken)
+// New creates a client for gitlab.com, or for a self-hosted GitLab instance if
+// opts.BaseURL is set, e.g. https://gitlab.example.com/api/v4.
+func New(personalAccessToken string, opts internal.Opts) (internal.Host, error) {
+	var clientOpts []gitlab.ClientOptionFunc
+	if opts.BaseURL != "" {
+		clientOpts = append(clientOpts, gitlab.WithBaseURL(opts.BaseURL))
+	}
+	client, err := gitlab.NewClient(personalAccessToken, clientOpts...)
 	if err != nil {
 		return gitlabClient{}, fmt.Errorf("failed to create client: %v", err)
 	}
 	return gitlabClient{
-		client: client,
+		client:         client,
+		sourceRepoPath: opts.SourceRepoPath,
 	}, nil
 }
 
@@ -52,6 +61,10 @@ func (g gitlabClient) GetChangeReqeuest(repoPath string, sourceBranch string) (i
 	if err != nil {
 		return internal.ChangeRequest{}, fmt.Errorf("failed to list merge requests: %w", err)
 	}
+	mergeRequests, err = g.filterBySourceProject(mergeRequests)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
 	switch len(mergeRequests) {
 	case 0:
 		return internal.ChangeRequest{}, fmt.Errorf("%w, source branch: %s", internal.ErrDoesNotExist, sourceBranch)
@@ -63,6 +76,88 @@ func (g gitlabClient) GetChangeReqeuest(repoPath string, sourceBranch string) (i
 	}
 }
 
+func (g gitlabClient) GetMergedChangeRequest(repoPath string, sourceBranch string) (internal.ChangeRequest, error) {
+	opts := &gitlab.ListProjectMergeRequestsOptions{
+		State:        gitlab.Ptr("merged"),
+		SourceBranch: &sourceBranch,
+		OrderBy:      gitlab.Ptr("updated_at"),
+		Sort:         gitlab.Ptr("desc"),
+	}
+	mergeRequests, _, err := g.client.MergeRequests.ListProjectMergeRequests(repoPath, opts)
+	if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+	mergeRequests, err = g.filterBySourceProject(mergeRequests)
+	if err != nil {
+		return internal.ChangeRequest{}, err
+	}
+	if len(mergeRequests) == 0 {
+		return internal.ChangeRequest{}, fmt.Errorf("%w, source branch: %s", internal.ErrDoesNotExist, sourceBranch)
+	}
+	return convertMR(mergeRequests[0]), nil
+}
+
+func (g gitlabClient) GetChangeRequestByID(repoPath string, id int) (internal.ChangeRequest, error) {
+	mr, resp, err := g.client.MergeRequests.GetMergeRequest(repoPath, id, &gitlab.GetMergeRequestsOptions{})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return internal.ChangeRequest{}, fmt.Errorf("%w, id: %d", internal.ErrDoesNotExist, id)
+	} else if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to get merge request: %w", err)
+	}
+	return convertMR(mr), nil
+}
+
+// GetChangeRequestStatus uses the merge request's latest pipeline and its approvals.
+func (g gitlabClient) GetChangeRequestStatus(repoPath string, cr internal.ChangeRequest) (internal.ChangeRequestStatus, error) {
+	// Derived from the same jobs as GetChecks, so that both always agree.
+	checks, err := g.GetChecks(repoPath, cr)
+	if err != nil {
+		return internal.ChangeRequestStatus{}, err
+	}
+	approvals, _, err := g.client.MergeRequestApprovals.GetConfiguration(repoPath, cr.ID)
+	if err != nil {
+		return internal.ChangeRequestStatus{}, fmt.Errorf("failed to get merge request approvals: %w", err)
+	}
+
+	var statuses []internal.CIStatus
+	for _, c := range checks {
+		statuses = append(statuses, c.Status)
+	}
+	return internal.ChangeRequestStatus{
+		CI:                internal.CombineCIStatuses(statuses),
+		Approvals:         len(approvals.ApprovedBy),
+		ApprovalsRequired: approvals.ApprovalsRequired,
+	}, nil
+}
+
+// GetChecks returns the jobs of the merge request's latest pipeline.
+func (g gitlabClient) GetChecks(repoPath string, cr internal.ChangeRequest) ([]internal.Check, error) {
+	pipelines, _, err := g.client.MergeRequests.ListMergeRequestPipelines(repoPath, cr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merge request pipelines: %w", err)
+	}
+	checks := []internal.Check{}
+	if len(pipelines) == 0 {
+		return checks, nil
+	}
+	// The pipeline may run in the source project if opening merge requests from a fork.
+	pipeline := pipelines[0]
+	jobs, _, err := g.client.Jobs.ListPipelineJobs(pipeline.ProjectID, pipeline.ID, &gitlab.ListJobsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline jobs: %w", err)
+	}
+	for _, job := range jobs {
+		checks = append(checks, internal.Check{
+			Name:   job.Name,
+			Status: convertJobStatus(job),
+			WebURL: job.WebURL,
+		})
+	}
+	return checks, nil
+}
+
 func (g gitlabClient) CreateChangeRequest(repoPath string, cr internal.ChangeRequest) (internal.ChangeRequest, error) {
 	if cr.Title == "" {
 		return internal.ChangeRequest{}, fmt.Errorf("merge request title cannot be empty")
@@ -75,7 +170,18 @@ func (g gitlabClient) CreateChangeRequest(repoPath string, cr internal.ChangeReq
 		TargetBranch: &cr.TargetBranch,
 	}
 
-	mr, _, err := g.client.MergeRequests.CreateMergeRequest(repoPath, opts)
+	// Merge requests from a fork are created in the fork, targeting the upstream project.
+	createIn := repoPath
+	if g.sourceRepoPath != "" {
+		project, _, err := g.client.Projects.GetProject(repoPath, &gitlab.GetProjectOptions{})
+		if err != nil {
+			return internal.ChangeRequest{}, fmt.Errorf("failed to get project %s: %w", repoPath, err)
+		}
+		opts.TargetProjectID = &project.ID
+		createIn = g.sourceRepoPath
+	}
+
+	mr, _, err := g.client.MergeRequests.CreateMergeRequest(createIn, opts)
 	if err != nil {
 		return internal.ChangeRequest{}, fmt.Errorf("failed to create merge request: %w", err)
 	}
@@ -125,7 +231,57 @@ func (g gitlabClient) CloseChangeRequest(repoPath string, cr internal.ChangeRequ
 	return convertMR(mr), nil
 }
 
+// MergeChangeRequest merges using the project's configured merge method, i.e. merge commit,
+// semi-linear or fast-forward. MergeMethodSquash additionally squashes the commits.
+func (g gitlabClient) MergeChangeRequest(repoPath string, cr internal.ChangeRequest, method internal.MergeMethod) (internal.ChangeRequest, error) {
+	if cr.ID == 0 {
+		return internal.ChangeRequest{}, fmt.Errorf("merge request ID must be set")
+	}
+	if method == internal.MergeMethodRebase {
+		return internal.ChangeRequest{}, fmt.Errorf(
+			"merge method %s is not supported for gitlab, configure fast-forward merges in the project settings instead", method)
+	}
+
+	opts := &gitlab.AcceptMergeRequestOptions{
+		Squash: gitlab.Ptr(method == internal.MergeMethodSquash),
+	}
+	mr, _, err := g.client.MergeRequests.AcceptMergeRequest(repoPath, cr.ID, opts)
+	if err != nil {
+		return internal.ChangeRequest{}, fmt.Errorf("failed to merge merge request: %w, mr: %+v", err, cr)
+	}
+
+	return convertMR(mr), nil
+}
+
+// filterBySourceProject drops merge requests from other forks if opening merge requests from a fork.
+func (g gitlabClient) filterBySourceProject(mergeRequests []*gitlab.MergeRequest) ([]*gitlab.MergeRequest, error) {
+	if g.sourceRepoPath == "" {
+		return mergeRequests, nil
+	}
+	project, _, err := g.client.Projects.GetProject(g.sourceRepoPath, &gitlab.GetProjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", g.sourceRepoPath, err)
+	}
+
+	var out []*gitlab.MergeRequest
+	for _, mr := range mergeRequests {
+		if mr.SourceProjectID == project.ID {
+			out = append(out, mr)
+		}
+	}
+	return out, nil
+}
+
 func convertMR(mr *gitlab.MergeRequest) internal.ChangeRequest {
+	var state internal.ChangeRequestState
+	switch mr.State {
+	case "merged":
+		state = internal.ChangeRequestStateMerged
+	case "closed", "locked":
+		state = internal.ChangeRequestStateClosed
+	default:
+		state = internal.ChangeRequestStateOpen
+	}
 	return internal.ChangeRequest{
 		ID:             mr.IID,
 		SourceBranch:   mr.SourceBranch,
@@ -134,5 +290,35 @@ func convertMR(mr *gitlab.MergeRequest) internal.ChangeRequest {
 		WebURL:         mr.WebURL,
 		MarkdownWebURL: fmt.Sprintf("%s+", mr.WebURL),
 		Title:          mr.Title,
+		State:          state,
+		Draft:          mr.Draft,
+		HeadCommit:     mr.SHA,
+		FromFork:       mr.SourceProjectID != mr.TargetProjectID,
+	}
+}
+
+func convertJobStatus(job *gitlab.Job) internal.CIStatus {
+	switch {
+	case job.Status == "manual" || job.Status == "skipped":
+		return internal.CIStatusNone
+	case job.Status == "failed" && job.AllowFailure:
+		// Doesn't fail the pipeline.
+		return internal.CIStatusSuccess
+	default:
+		return convertPipelineStatus(job.Status)
+	}
+}
+
+func convertPipelineStatus(status string) internal.CIStatus {
+	switch status {
+	case "success":
+		return internal.CIStatusSuccess
+	case "failed", "canceled":
+		return internal.CIStatusFailure
+	case "skipped":
+		return internal.CIStatusNone
+	default:
+		// e.g. created, pending, running, manual, scheduled
+		return internal.CIStatusPending
 	}
 }
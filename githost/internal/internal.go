@@ -12,6 +12,82 @@ type ChangeRequest struct {
 	TargetBranch   string
 	WebURL         string
 	MarkdownWebURL string
+	State          ChangeRequestState
+	Draft          bool
+	// The commit at the tip of the source branch, as last pushed.
+	HeadCommit string
+	// Whether the source branch is in a different repo than the target branch, e.g. a fork.
+	FromFork bool
+}
+
+type ChangeRequestState string
+
+const (
+	ChangeRequestStateOpen   ChangeRequestState = "OPEN"
+	ChangeRequestStateMerged ChangeRequestState = "MERGED"
+	ChangeRequestStateClosed ChangeRequestState = "CLOSED"
+)
+
+// ChangeRequestStatus is the CI and review status of a change request.
+type ChangeRequestStatus struct {
+	CI        CIStatus
+	Approvals int
+	// The number of approvals needed before merging, or 0 if the host doesn't report it.
+	ApprovalsRequired int
+	// Whether any reviewer's latest review requests changes.
+	ChangesRequested bool
+}
+
+// CIStatus is the combined status of the pipelines or checks that ran on a change request's head commit.
+type CIStatus string
+
+const (
+	CIStatusNone    CIStatus = "NONE"
+	CIStatusPending CIStatus = "PENDING"
+	CIStatusSuccess CIStatus = "SUCCESS"
+	CIStatusFailure CIStatus = "FAILURE"
+)
+
+// CombineCIStatuses returns the worst of the given statuses: any failure fails, then any pending is pending.
+// Statuses of checks that didn't run are ignored.
+func CombineCIStatuses(statuses []CIStatus) CIStatus {
+	combined := CIStatusNone
+	for _, s := range statuses {
+		switch {
+		case s == CIStatusFailure:
+			return CIStatusFailure
+		case s == CIStatusPending:
+			combined = CIStatusPending
+		case s == CIStatusSuccess && combined == CIStatusNone:
+			combined = CIStatusSuccess
+		}
+	}
+	return combined
+}
+
+// Check is a CI job or check run on a change request's head commit.
+type Check struct {
+	Name   string
+	Status CIStatus
+	WebURL string
+}
+
+// MergeMethod is how a change request's commits are added to the target branch.
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// Opts configures a Host.
+type Opts struct {
+	// The API base URL of a self-hosted instance. Defaults to the public API if empty.
+	BaseURL string
+	// The repo that source branches are pushed to when opening change requests from a fork.
+	// Defaults to the repo that change requests are opened in.
+	SourceRepoPath string
 }
 
 type Repo struct {
@@ -28,12 +104,25 @@ type Vocabulary struct {
 	ChangeRequestNameShortPlural string
 }
 
+// All repoPath params refer to the repo that change requests are opened in,
+// see Opts.SourceRepoPath for the repo that source branches belong to.
 type Host interface {
 	GetVocabulary() Vocabulary
 	GetRepo(repoPath string) (Repo, error)
 	// Returns ErrDoesNotExist if no change request exists for the given sourceBranch
 	GetChangeReqeuest(repoPath string, sourceBranch string) (ChangeRequest, error)
+	// Returns the most recently merged change request for the given sourceBranch,
+	// or ErrDoesNotExist if none exists.
+	GetMergedChangeRequest(repoPath string, sourceBranch string) (ChangeRequest, error)
+	// Returns ErrDoesNotExist if no change request exists with the given ID
+	GetChangeRequestByID(repoPath string, id int) (ChangeRequest, error)
+	GetChangeRequestStatus(repoPath string, r ChangeRequest) (ChangeRequestStatus, error)
+	// Returns the checks that ran on the change request's head commit, or an empty slice if none ran.
+	GetChecks(repoPath string, r ChangeRequest) ([]Check, error)
 	UpdateChangeRequest(repoPath string, r ChangeRequest) (ChangeRequest, error)
 	CreateChangeRequest(repoPath string, r ChangeRequest) (ChangeRequest, error)
 	CloseChangeRequest(repoPath string, r ChangeRequest) (ChangeRequest, error)
+	// Merging may complete asynchronously, the returned change request is not
+	// guaranteed to be in the merged state yet.
+	MergeChangeRequest(repoPath string, r ChangeRequest, method MergeMethod) (ChangeRequest, error)
 }
@@ -0,0 +1,96 @@
+package libgit
+
+import (
+	"fmt"
+
+	"github.com/raymondji/git-stack-cli/exec"
+)
+
+// EditState records a branch checked out for editing in the middle of a stack, so that the branches
+// above it can be rebased onto it afterwards. It is stored in the repo's git config.
+type EditState struct {
+	// The branch being edited.
+	Branch string
+	// The commit the edited branch was at before editing, which the branches above it are still based on.
+	OriginalCommit string
+	// The top of the stack, the branches up to it are rebased after editing.
+	Top string
+	// The branch that was checked out before editing.
+	ReturnTo string
+}
+
+// GetEditState returns the edit in progress, or false if there is none.
+func (g git) GetEditState() (EditState, bool, error) {
+	output, err := exec.Run(
+		"git",
+		exec.WithArgs("config", "--get-regexp", `^stack\.edit\.`),
+		exec.WithIgnoreExitError(),
+	)
+	if err != nil {
+		return EditState{}, false, fmt.Errorf("failed to get edit state, err: %v", err)
+	}
+	// Exit code 1 means no matching config was found.
+	if output.ExitCode == 1 {
+		return EditState{}, false, nil
+	} else if output.ExitCode != 0 {
+		return EditState{}, false, fmt.Errorf("failed to get edit state, err: %v", output.Stderr)
+	}
+
+	var state EditState
+	for _, line := range output.Lines() {
+		var key, value string
+		if _, err := fmt.Sscan(line, &key, &value); err != nil {
+			return EditState{}, false, fmt.Errorf("unexpected git config line: %s", line)
+		}
+		// Keys are lowercased by git config.
+		switch key {
+		case "stack.edit.branch":
+			state.Branch = value
+		case "stack.edit.originalcommit":
+			state.OriginalCommit = value
+		case "stack.edit.top":
+			state.Top = value
+		case "stack.edit.returnto":
+			state.ReturnTo = value
+		}
+	}
+	if state.Branch == "" || state.OriginalCommit == "" || state.ReturnTo == "" {
+		return EditState{}, false, fmt.Errorf("incomplete edit state in git config: %+v", state)
+	}
+	// Edits started by older versions only recorded the branch to return to.
+	if state.Top == "" {
+		state.Top = state.ReturnTo
+	}
+	return state, true, nil
+}
+
+func (g git) SetEditState(state EditState) error {
+	values := map[string]string{
+		"stack.edit.branch":         state.Branch,
+		"stack.edit.originalCommit": state.OriginalCommit,
+		"stack.edit.top":            state.Top,
+		"stack.edit.returnTo":       state.ReturnTo,
+	}
+	for key, value := range values {
+		if _, err := exec.Run("git", exec.WithArgs("config", key, value)); err != nil {
+			return fmt.Errorf("failed to set edit state, err: %v", err)
+		}
+	}
+	return nil
+}
+
+func (g git) UnsetEditState() error {
+	output, err := exec.Run(
+		"git",
+		exec.WithArgs("config", "--remove-section", "stack.edit"),
+		exec.WithIgnoreExitError(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unset edit state, err: %v", err)
+	}
+	// Exit code 128 means the section doesn't exist.
+	if output.ExitCode != 0 && output.ExitCode != 128 {
+		return fmt.Errorf("failed to unset edit state, err: %v", output.Stderr)
+	}
+	return nil
+}
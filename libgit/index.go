@@ -0,0 +1,92 @@
+package libgit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/exec"
+)
+
+// GetStagedDiff returns the staged changes without context lines, see absorb.ParseDiff.
+func (g git) GetStagedDiff() (string, error) {
+	// Written to a file rather than read from stdout, which is trimmed, since trailing
+	// whitespace in the last line is part of the diff.
+	dir, err := os.MkdirTemp("", "git-stack-diff")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir, err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "staged.diff")
+
+	_, err = exec.Run("git", exec.WithArgs(
+		"diff", "--cached", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames",
+		"--src-prefix=a/", "--dst-prefix=b/", "--output="+path,
+	))
+	if err != nil {
+		return "", fmt.Errorf("failed to diff staged changes, err: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged changes, err: %v", err)
+	}
+	return string(data), nil
+}
+
+// BlameLines returns the full hash of the commit that last changed each line in the range, as of rev.
+func (g git) BlameLines(rev string, file string, start int, end int) ([]string, error) {
+	output, err := exec.Run("git", exec.WithArgs(
+		"blame", "-l", "-s", "-L", fmt.Sprintf("%d,%d", start, end), rev, "--", file,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s, err: %v", file, err)
+	}
+	var hashes []string
+	for _, line := range output.Lines() {
+		hash, _, _ := strings.Cut(line, " ")
+		// Boundary commits are prefixed with ^.
+		hashes = append(hashes, strings.TrimPrefix(hash, "^"))
+	}
+	return hashes, nil
+}
+
+// WriteTree saves the index as a tree object and returns its hash.
+func (g git) WriteTree() (string, error) {
+	output, err := exec.Run("git", exec.WithArgs("write-tree"))
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree, err: %v", err)
+	}
+	return output.Stdout, nil
+}
+
+// ReadTree replaces the index with the given tree, leaving the working tree unchanged.
+func (g git) ReadTree(tree string) error {
+	_, err := exec.Run("git", exec.WithArgs("read-tree", tree))
+	if err != nil {
+		return fmt.Errorf("failed to read tree %s, err: %v", tree, err)
+	}
+	return nil
+}
+
+// ApplyToIndex applies a patch without context lines to the index, leaving the working tree unchanged.
+func (g git) ApplyToIndex(patch string) error {
+	f, err := os.CreateTemp("", "git-stack-*.patch")
+	if err != nil {
+		return fmt.Errorf("failed to create patch file, err: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(patch); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write patch file, err: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write patch file, err: %v", err)
+	}
+
+	_, err = exec.Run("git", exec.WithArgs("apply", "--cached", "--unidiff-zero", f.Name()))
+	if err != nil {
+		return fmt.Errorf("failed to apply patch, err: %v", err)
+	}
+	return nil
+}
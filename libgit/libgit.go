@@ -3,6 +3,7 @@ package libgit
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,21 +18,60 @@ var minGitVersion = version{major: 2, minor: 38}
 type Git interface {
 	ValidateGitInstall() error
 	IsRepoClean() (bool, error)
-	GetRemote() (Remote, error)
+	GetRemote(name string) (Remote, error)
 	GetRootDir() (string, error)
+	GetGitDir() (string, error)
 	CommitFixup(commitHash string, add bool) (string, error)
 	CommitEmpty(msg string) error
+	Commit(opts CommitOpts) error
+	HasChangesToCommit(all bool) (bool, error)
+	HasUnstagedChanges() (bool, error)
 	GetMergedBranches(ref string) ([]string, error)
+	IsSquashMerged(branch string, base string, ref string) (bool, error)
+	GetMergeBase(a string, b string) (string, error)
+	IsAncestor(ancestor string, descendant string) (bool, error)
+	CountAheadBehind(ref string, upstream string) (int, int, error)
 	GetCurrentBranch() (string, error)
 	GetShortCommitHash(branch string) (string, error)
-	Push(branchName string, opts PushOpts) (string, error)
-	Rebase(branch string, opts RebaseOpts) (string, error)
+	Fetch(remote string) error
+	FetchBranches(remote string, branches []string) error
+	FastForward(branch string, upstream string) error
+	Push(remote string, branchName string, opts PushOpts) (string, error)
+	Rebase(upstream string, opts RebaseOpts) (string, error)
+	GetRebaseState() (RebaseState, bool, error)
+	CherryPick(from string, to string) error
+	CherryPickAbort() error
+	IsCherryPickInProgress() (bool, error)
+	GetConflictedFiles() ([]string, error)
+	RebaseContinue() error
+	RebaseAbort() error
 	CreateBranch(name string, startPoint string) error
+	CreateTrackingBranch(name string, upstream Upstream) error
+	RenameBranch(oldName string, newName string) error
 	DeleteBranchIfExists(name string) error
-	DeleteRemoteBranchIfExists(name string) error
+	DeleteRemoteBranchIfExists(remote string, name string) error
 	Checkout(name string) error
-	LogAll(notReachableFrom string) (Log, error)
+	ResetHard(ref string) error
+	GetBranchHashes() (map[string]string, error)
+	GetRefs(prefix string) ([]string, error)
+	UpdateRef(ref string, hash string) error
+	UpdateRefs(updates []RefUpdate) error
+	GetStackBases() (map[string]string, error)
+	SetStackBase(branch string, base string) error
+	UnsetStackBase(branch string) error
+	GetEditState() (EditState, bool, error)
+	SetEditState(state EditState) error
+	UnsetEditState() error
+	LogAll(notReachableFrom ...string) (Log, error)
+	Log(from string, tos ...string) (Log, error)
 	LogOneline(from string, to string) error
+	LogGraph(from string, tos ...string) error
+	Diff(from string, to string, opts DiffOpts) error
+	GetStagedDiff() (string, error)
+	BlameLines(rev string, file string, start int, end int) ([]string, error)
+	WriteTree() (string, error)
+	ReadTree(tree string) error
+	ApplyToIndex(patch string) error
 }
 
 type git struct{}
@@ -119,51 +159,53 @@ func (g git) IsRepoClean() (bool, error) {
 }
 
 type Remote struct {
-	Kind    githost.Kind
-	URLPath string // e.g. raymondji/git-stack-cli
+	Name string // e.g. origin
+	// Empty if the kind of host could not be detected, e.g. for self-hosted instances.
+	Kind     githost.Kind
+	Hostname string // e.g. github.com
+	URLPath  string // e.g. raymondji/git-stack-cli
 }
 
-func (g git) GetRemote() (Remote, error) {
+func (g git) GetRemote(name string) (Remote, error) {
 	output, err := exec.Run(
 		"git",
 		exec.WithArgs(
-			"remote", "get-url", "origin",
+			"remote", "get-url", name,
 		),
 	)
 	if err != nil {
-		return Remote{}, fmt.Errorf("failed to get upstream, err: %v", err)
+		return Remote{}, fmt.Errorf("failed to get url of remote %s, err: %v", name, err)
+	}
+	return parseRemoteURL(name, output.Stdout)
+}
+
+func parseRemoteURL(name string, url string) (Remote, error) {
+	u, err := giturls.Parse(url)
+	if err != nil {
+		return Remote{}, fmt.Errorf("failed to parse %s url %q, err: %v", name, url, err)
 	}
 
+	hostname := u.Host
+	if u.Scheme != "http" && u.Scheme != "https" {
+		// Drop the ssh port, it's not relevant for the API.
+		hostname = u.Hostname()
+	}
 	var kind githost.Kind
 	switch {
-	case strings.Contains(output.Stdout, "gitlab.com"):
+	case strings.Contains(hostname, "gitlab.com"):
 		kind = githost.Gitlab
-	case strings.Contains(output.Stdout, "github.com"):
+	case strings.Contains(hostname, "github.com"):
 		kind = githost.Github
-	default:
-		return Remote{}, fmt.Errorf("unsupported git host: %s", output.Stdout)
-	}
-
-	// Extract the repository name
-	path, err := parseRepoPathFromRemoteURL(output.Stdout)
-	if err != nil {
-		return Remote{}, err
-	}
-	return Remote{
-		URLPath: path,
-		Kind:    kind,
-	}, nil
-}
-
-func parseRepoPathFromRemoteURL(url string) (string, error) {
-	u, err := giturls.Parse(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse origin url %q, err: %v", url, err)
 	}
 
 	path := strings.TrimSuffix(u.Path, ".git")
 	path = strings.TrimPrefix(path, "/")
-	return path, nil
+	return Remote{
+		Name:     name,
+		Kind:     kind,
+		Hostname: hostname,
+		URLPath:  path,
+	}, nil
 }
 
 func (g git) GetUpstream(branch string) (Upstream, error) {
@@ -204,6 +246,15 @@ func (g git) GetRootDir() (string, error) {
 	return output.Stdout, nil
 }
 
+// GetGitDir returns the absolute path to the git dir shared by all worktrees.
+func (g git) GetGitDir() (string, error) {
+	output, err := exec.Run("git", exec.WithArgs("rev-parse", "--path-format=absolute", "--git-common-dir"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get git dir, err: %v", err)
+	}
+	return output.Stdout, nil
+}
+
 func (g git) CommitFixup(commitHash string, add bool) (string, error) {
 	args := []string{"commit", "-m", fmt.Sprintf("fixup! %s", commitHash)}
 	if add {
@@ -224,14 +275,69 @@ func (g git) CommitEmpty(msg string) error {
 	return nil
 }
 
-func (g git) Commit(msg string) error {
-	_, err := exec.Run("git", exec.WithArgs("commit", "-a", "-m", msg))
+type CommitOpts struct {
+	// If empty, git opens an editor to write the commit message.
+	Message string
+	// If true, stage all changes to tracked files before committing, see git commit -a.
+	All bool
+}
+
+func (g git) Commit(opts CommitOpts) error {
+	args := []string{"commit"}
+	if opts.All {
+		args = append(args, "-a")
+	}
+	if opts.Message != "" {
+		args = append(args, "-m", opts.Message)
+	}
+	_, err := exec.Run("git", exec.WithArgs(args...), exec.WithInteractive(opts.Message == ""))
 	if err != nil {
 		return fmt.Errorf("failed to commit, err: %v", err)
 	}
 	return nil
 }
 
+// HasChangesToCommit returns whether there are staged changes, or if all is true, whether
+// there are any changes to tracked files.
+func (g git) HasChangesToCommit(all bool) (bool, error) {
+	args := []string{"diff", "--quiet", "--cached"}
+	if all {
+		args = []string{"diff", "--quiet", "HEAD"}
+	}
+	output, err := exec.Run("git", exec.WithArgs(args...), exec.WithIgnoreExitError())
+	if err != nil {
+		return false, fmt.Errorf("failed to check for changes, err: %v", err)
+	}
+	switch output.ExitCode {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to check for changes, err: %v", output.Stderr)
+	}
+}
+
+// HasUnstagedChanges returns whether the working tree differs from the index, including untracked files.
+func (g git) HasUnstagedChanges() (bool, error) {
+	output, err := exec.Run("git", exec.WithArgs("diff", "--quiet"), exec.WithIgnoreExitError())
+	if err != nil {
+		return false, fmt.Errorf("failed to check for unstaged changes, err: %v", err)
+	}
+	switch output.ExitCode {
+	case 0:
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to check for unstaged changes, err: %v", output.Stderr)
+	}
+	output, err = exec.Run("git", exec.WithArgs("ls-files", "--others", "--exclude-standard"))
+	if err != nil {
+		return false, fmt.Errorf("failed to list untracked files, err: %v", err)
+	}
+	return output.Stdout != "", nil
+}
+
 func (g git) GetCurrentBranch() (string, error) {
 	output, err := exec.Run("git", exec.WithArgs("rev-parse", "--abbrev-ref", "HEAD"))
 	if err != nil {
@@ -254,8 +360,8 @@ type PushOpts struct {
 	ForceIfIncludes bool
 }
 
-func (g git) Push(branchName string, opts PushOpts) (string, error) {
-	args := []string{"push", "origin", branchName}
+func (g git) Push(remote string, branchName string, opts PushOpts) (string, error) {
+	args := []string{"push", remote, branchName}
 	if opts.Force {
 		args = append(args, "--force")
 	}
@@ -274,16 +380,69 @@ func (g git) Push(branchName string, opts PushOpts) (string, error) {
 	return output.Stdout, nil
 }
 
+func (g git) Fetch(remote string) error {
+	_, err := exec.Run("git", exec.WithArgs("fetch", remote, "--prune"))
+	if err != nil {
+		return fmt.Errorf("failed to fetch, err: %v", err)
+	}
+	return nil
+}
+
+// FetchBranches updates the remote-tracking branches of the given branches only.
+func (g git) FetchBranches(remote string, branches []string) error {
+	args := []string{"fetch", remote}
+	for _, b := range branches {
+		args = append(args, fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", b, remote, b))
+	}
+	_, err := exec.Run("git", exec.WithArgs(args...))
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s, err: %v", strings.Join(branches, ", "), err)
+	}
+	return nil
+}
+
+// FastForward updates branch to upstream, failing if it is not a fast-forward.
+func (g git) FastForward(branch string, upstream string) error {
+	currBranch, err := g.GetCurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if currBranch == branch {
+		args = []string{"merge", "--ff-only", upstream}
+	} else {
+		// Fetching from the local repo only updates the ref if it's a fast-forward.
+		args = []string{"fetch", ".", fmt.Sprintf("%s:%s", upstream, branch)}
+	}
+	_, err = exec.Run("git", exec.WithArgs(args...))
+	if err != nil {
+		return fmt.Errorf("failed to fast-forward %s to %s, err: %v", branch, upstream, err)
+	}
+	return nil
+}
+
 type RebaseOpts struct {
 	Interactive bool
 	Autosquash  bool
 	KeepBase    bool
 	UpdateRefs  bool
+	// If set, rebase onto this ref instead of upstream, see git rebase --onto.
+	Onto string
+	// If set, checkout this branch before rebasing.
+	Branch string
 }
 
-func (g git) Rebase(branch string, opts RebaseOpts) (string, error) {
+func (g git) Rebase(upstream string, opts RebaseOpts) (string, error) {
 	env := []string{}
-	args := []string{"rebase", branch}
+	args := []string{"rebase"}
+	if opts.Onto != "" {
+		args = append(args, "--onto", opts.Onto)
+	}
+	args = append(args, upstream)
+	if opts.Branch != "" {
+		args = append(args, opts.Branch)
+	}
 	if opts.KeepBase {
 		args = append(args, "--keep-base")
 	}
@@ -331,6 +490,132 @@ func (g git) GetMergedBranches(ref string) ([]string, error) {
 	return branches, nil
 }
 
+func (g git) GetMergeBase(a string, b string) (string, error) {
+	output, err := exec.Run("git", exec.WithArgs("merge-base", a, b))
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base of %s and %s, err: %v", a, b, err)
+	}
+	return output.Stdout, nil
+}
+
+// IsAncestor returns whether ancestor is reachable from descendant, a commit is its own ancestor.
+func (g git) IsAncestor(ancestor string, descendant string) (bool, error) {
+	output, err := exec.Run("git", exec.WithArgs("merge-base", "--is-ancestor", ancestor, descendant), exec.WithIgnoreExitError())
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is an ancestor of %s, err: %v", ancestor, descendant, err)
+	}
+	switch output.ExitCode {
+	case 0:
+		return true, nil
+	case 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check if %s is an ancestor of %s, err: %v", ancestor, descendant, output.Stderr)
+	}
+}
+
+// CountAheadBehind returns the number of commits in ref that aren't in upstream, and the number
+// of commits in upstream that aren't in ref.
+func (g git) CountAheadBehind(ref string, upstream string) (int, int, error) {
+	output, err := exec.Run("git", exec.WithArgs("rev-list", "--left-right", "--count", fmt.Sprintf("%s...%s", ref, upstream)))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare %s with %s, err: %v", ref, upstream, err)
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscanf(output.Stdout, "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse rev-list output %q, err: %v", output.Stdout, err)
+	}
+	return ahead, behind, nil
+}
+
+// IsSquashMerged returns whether the changes on branch since base have landed in ref
+// without branch being an ancestor of ref, i.e. through a squash merge or by rebasing
+// each commit onto ref. If base is empty, the merge base of branch and ref is used.
+func (g git) IsSquashMerged(branch string, base string, ref string) (bool, error) {
+	if base == "" {
+		var err error
+		base, err = g.GetMergeBase(ref, branch)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Rebase merged: every commit has an equivalent patch in ref.
+	output, err := exec.Run("git", exec.WithArgs("cherry", ref, branch, base))
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s with %s, err: %v", branch, ref, err)
+	}
+	lines := output.Lines()
+	if len(lines) == 0 {
+		return false, nil
+	}
+	if allEquivalent(lines) {
+		return true, nil
+	}
+
+	// Squash merged: the cumulative diff has an equivalent patch in ref. Compare patch ids
+	// directly instead of committing the diff for git cherry, so that no objects are written.
+	output, err = exec.Run("git", exec.WithArgs("diff", "--no-color", "--no-ext-diff", base, branch))
+	if err != nil {
+		return false, fmt.Errorf("failed to diff %s, err: %v", branch, err)
+	}
+	if output.Stdout == "" {
+		return false, nil
+	}
+	want, err := patchIDs(output.Stdout)
+	if err != nil {
+		return false, err
+	}
+	mergeBase, err := g.GetMergeBase(ref, branch)
+	if err != nil {
+		return false, err
+	}
+	output, err = exec.Run("git", exec.WithArgs(
+		"log", "-p", "--no-merges", "--no-color", "--no-ext-diff", mergeBase+".."+ref,
+	))
+	if err != nil {
+		return false, fmt.Errorf("failed to log %s, err: %v", ref, err)
+	}
+	if output.Stdout == "" {
+		return false, nil
+	}
+	got, err := patchIDs(output.Stdout)
+	if err != nil {
+		return false, err
+	}
+	return len(want) == 1 && slices.Contains(got, want[0]), nil
+}
+
+// patchIDs returns the stable patch id of each commit in the output of git log -p,
+// or of the diff itself for git diff output.
+func patchIDs(patch string) ([]string, error) {
+	// Stdout is trimmed, but git patch-id expects the patch to end with a newline.
+	output, err := exec.Run("git", exec.WithArgs("patch-id", "--stable"), exec.WithStdin(patch+"\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch ids, err: %v", err)
+	}
+	var ids []string
+	for _, line := range output.Lines() {
+		id, _, _ := strings.Cut(line, " ")
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// allEquivalent returns whether every line of git cherry output is marked as having
+// an equivalent commit upstream.
+func allEquivalent(cherryLines []string) bool {
+	if len(cherryLines) == 0 {
+		return false
+	}
+	for _, line := range cherryLines {
+		if !strings.HasPrefix(line, "-") {
+			return false
+		}
+	}
+	return true
+}
+
 func (g git) CreateBranch(name string, startPoint string) error {
 	_, err := exec.Run("git", exec.WithArgs("branch", name, startPoint))
 	if err != nil {
@@ -339,6 +624,25 @@ func (g git) CreateBranch(name string, startPoint string) error {
 	return nil
 }
 
+// CreateTrackingBranch creates a branch from its remote-tracking branch, with upstream set as its upstream.
+func (g git) CreateTrackingBranch(name string, upstream Upstream) error {
+	startPoint := fmt.Sprintf("%s/%s", upstream.Remote, upstream.BranchName)
+	_, err := exec.Run("git", exec.WithArgs("branch", "--track", name, startPoint))
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s, err: %v", name, err)
+	}
+	return nil
+}
+
+// RenameBranch renames a local branch, along with its config and reflog.
+func (g git) RenameBranch(oldName string, newName string) error {
+	_, err := exec.Run("git", exec.WithArgs("branch", "-m", oldName, newName))
+	if err != nil {
+		return fmt.Errorf("failed to rename branch %s, err: %v", oldName, err)
+	}
+	return nil
+}
+
 func (g git) DeleteBranchIfExists(name string) error {
 	_, err := exec.Run("git", exec.WithArgs("branch", "-D", name))
 	if err != nil {
@@ -350,8 +654,8 @@ func (g git) DeleteBranchIfExists(name string) error {
 	return nil
 }
 
-func (g git) DeleteRemoteBranchIfExists(name string) error {
-	_, err := exec.Run("git", exec.WithArgs("push", "origin", "--delete", name))
+func (g git) DeleteRemoteBranchIfExists(remote string, name string) error {
+	_, err := exec.Run("git", exec.WithArgs("push", remote, "--delete", name))
 	if err != nil {
 		if strings.Contains(err.Error(), "remote ref does not exist") {
 			return nil
@@ -369,12 +673,150 @@ func (g git) Checkout(name string) error {
 	return nil
 }
 
+// GetStackBases returns the base branch configured for each branch, see SetStackBase.
+// Keys are branch names, values are base branches. Bases that no longer exist are ignored.
+func (g git) GetStackBases() (map[string]string, error) {
+	output, err := exec.Run(
+		"git",
+		exec.WithArgs("config", "--get-regexp", `^branch\..*\.stackbase$`),
+		exec.WithIgnoreExitError(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack bases, err: %v", err)
+	}
+	// Exit code 1 means no matching config was found.
+	if output.ExitCode == 1 {
+		return map[string]string{}, nil
+	} else if output.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to get stack bases, err: %v", output.Stderr)
+	}
+
+	branchesOutput, err := exec.Run("git", exec.WithArgs("branch", "--format=%(refname:short)"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches, err: %v", err)
+	}
+	exists := map[string]struct{}{}
+	for _, b := range branchesOutput.Lines() {
+		exists[b] = struct{}{}
+	}
+
+	bases := map[string]string{}
+	for _, line := range output.Lines() {
+		key, base, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected git config line: %s", line)
+		}
+		branch := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".stackbase")
+		if _, ok := exists[base]; !ok {
+			continue
+		}
+		bases[branch] = base
+	}
+	return bases, nil
+}
+
+// SetStackBase records that branch is part of a stack based on base instead of the default branch.
+func (g git) SetStackBase(branch string, base string) error {
+	_, err := exec.Run("git", exec.WithArgs("config", fmt.Sprintf("branch.%s.stackBase", branch), base))
+	if err != nil {
+		return fmt.Errorf("failed to set stack base for %s, err: %v", branch, err)
+	}
+	return nil
+}
+
+func (g git) UnsetStackBase(branch string) error {
+	output, err := exec.Run(
+		"git",
+		exec.WithArgs("config", "--unset", fmt.Sprintf("branch.%s.stackBase", branch)),
+		exec.WithIgnoreExitError(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unset stack base for %s, err: %v", branch, err)
+	}
+	// Exit code 5 means the config was not set.
+	if output.ExitCode != 0 && output.ExitCode != 5 {
+		return fmt.Errorf("failed to unset stack base for %s, err: %v", branch, output.Stderr)
+	}
+	return nil
+}
+
+func (g git) ResetHard(ref string) error {
+	_, err := exec.Run("git", exec.WithArgs("reset", "--hard", ref))
+	if err != nil {
+		return fmt.Errorf("failed to reset to %s, err: %v", ref, err)
+	}
+	return nil
+}
+
+// GetBranchHashes returns the full commit hash of every local branch, keyed by branch name.
+func (g git) GetBranchHashes() (map[string]string, error) {
+	output, err := exec.Run("git", exec.WithArgs("for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches, err: %v", err)
+	}
+
+	hashes := map[string]string{}
+	for _, line := range output.Lines() {
+		branch, hash, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected git for-each-ref line: %s", line)
+		}
+		hashes[branch] = hash
+	}
+	return hashes, nil
+}
+
+// GetRefs returns the full names of all refs starting with prefix, e.g. refs/heads/.
+func (g git) GetRefs(prefix string) ([]string, error) {
+	output, err := exec.Run("git", exec.WithArgs("for-each-ref", "--format=%(refname)", prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs, err: %v", err)
+	}
+	return output.Lines(), nil
+}
+
+func (g git) UpdateRef(ref string, hash string) error {
+	_, err := exec.Run("git", exec.WithArgs("update-ref", ref, hash))
+	if err != nil {
+		return fmt.Errorf("failed to update %s, err: %v", ref, err)
+	}
+	return nil
+}
+
+// RefUpdate sets Ref to Hash, or deletes Ref if Hash is empty.
+type RefUpdate struct {
+	Ref  string
+	Hash string
+}
+
+// UpdateRefs applies all updates in a single git process and transaction, so that either
+// all of them or none of them are applied.
+func (g git) UpdateRefs(updates []RefUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	for _, u := range updates {
+		if u.Hash == "" {
+			fmt.Fprintf(&sb, "delete %s\n", u.Ref)
+		} else {
+			fmt.Fprintf(&sb, "update %s %s\n", u.Ref, u.Hash)
+		}
+	}
+	_, err := exec.Run("git", exec.WithArgs("update-ref", "--stdin"), exec.WithStdin(sb.String()))
+	if err != nil {
+		return fmt.Errorf("failed to update refs, err: %v", err)
+	}
+	return nil
+}
+
 type Log struct {
-	// Commits are ordered from oldest to newest
+	// Commits are ordered from newest to oldest
 	Commits []Commit
 }
 
 type Commit struct {
+	// The author date in strict ISO 8601 format, e.g. 2024-01-02T15:04:05+01:00.
 	Date          string
 	Subject       string
 	Author        string
@@ -397,24 +839,81 @@ func (g git) LogOneline(from string, to string) error {
 	return nil
 }
 
+// LogGraph prints the commits reachable from any of tos but not from, as a graph.
+func (g git) LogGraph(from string, tos ...string) error {
+	args := []string{"log", "--oneline", "--graph", fmt.Sprintf("^%s", from)}
+	args = append(args, tos...)
+	_, err := exec.Run(
+		"git",
+		exec.WithArgs(args...),
+		exec.WithOSStdout(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve git log: %v", err)
+	}
+	return nil
+}
+
+type DiffOpts struct {
+	Stat     bool
+	NameOnly bool
+	// Print directly instead of through git's pager, e.g. when printing several diffs in a row.
+	NoPager bool
+}
+
+// Diff prints the changes on to since it forked from from, i.e. ignoring changes made on from since then.
+func (g git) Diff(from string, to string, opts DiffOpts) error {
+	var args []string
+	if opts.NoPager {
+		args = append(args, "--no-pager")
+	}
+	args = append(args, "diff")
+	if opts.Stat {
+		args = append(args, "--stat")
+	}
+	if opts.NameOnly {
+		args = append(args, "--name-only")
+	}
+	args = append(args, fmt.Sprintf("%s...%s", from, to))
+	_, err := exec.Run("git", exec.WithArgs(args...), exec.WithOSStdout())
+	if err != nil {
+		return fmt.Errorf("failed to diff %s...%s, err: %v", from, to, err)
+	}
+	return nil
+}
+
 // Is there any advantage to using git rev-list --parents --branches instead?
 // Seems to be about the same, git git rev-list would need to do a separate
 // git branch call to map branch refs to commit hashes
-func (g git) LogAll(notReachableFrom string) (Log, error) {
-	output, err := exec.Run(
-		"git",
-		exec.WithArgs(
-			"log",
-			`--pretty=format:%h-----%p-----%D-----%an-----%ar-----%s`,
-			"--decorate=full",
-			"--branches", fmt.Sprintf("^%s", notReachableFrom),
-		),
-	)
+func (g git) LogAll(notReachableFrom ...string) (Log, error) {
+	args := []string{"--branches"}
+	for _, ref := range notReachableFrom {
+		args = append(args, fmt.Sprintf("^%s", ref))
+	}
+	return g.log(args...)
+}
+
+// Log returns the commits reachable from any of tos but not from, newest first.
+func (g git) Log(from string, tos ...string) (Log, error) {
+	args := []string{fmt.Sprintf("^%s", from)}
+	args = append(args, tos...)
+	return g.log(args...)
+}
+
+func (g git) log(revs ...string) (Log, error) {
+	args := []string{
+		"log",
+		`--pretty=format:%h-----%p-----%D-----%an-----%aI-----%s`,
+		"--decorate=full",
+	}
+	args = append(args, revs...)
+	args = append(args, "--")
+	output, err := exec.Run("git", exec.WithArgs(args...))
 	if err != nil {
 		return Log{}, fmt.Errorf("failed to retrieve git log: %v", err)
 	}
 	lines := output.Lines()
-	slog.Debug("git.LogAll", "output", lines, "len output", len(lines))
+	slog.Debug("git.log", "output", lines, "len output", len(lines))
 
 	var commits []Commit
 	for _, line := range lines {
@@ -444,7 +943,7 @@ func (g git) LogAll(notReachableFrom string) (Log, error) {
 		commits = append(commits, commit)
 	}
 
-	slog.Debug("git.LogAll", "commits", commits)
+	slog.Debug("git.log", "commits", commits)
 	return Log{
 		Commits: commits,
 	}, nil
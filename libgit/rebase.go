@@ -0,0 +1,167 @@
+package libgit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/raymondji/git-stack-cli/exec"
+)
+
+const nullHash = "0000000000000000000000000000000000000000"
+
+// RebaseState describes an in-progress rebase, as recorded by git in .git/rebase-merge.
+type RebaseState struct {
+	// The branch being rebased, empty if rebasing a detached HEAD.
+	Branch string
+	// The commit that the rebase stopped at, e.g. due to a conflict.
+	StoppedAt string
+	// Branches that --update-refs already moved to their new commits.
+	// Git writes the refs when the rebase finishes, so these are still at their old commits until then.
+	UpdatedBranches []string
+	// Branches that --update-refs has yet to replay, ordered from the bottom of the stack.
+	PendingBranches []string
+}
+
+// CurrentBranch returns the branch whose commits are being replayed.
+func (s RebaseState) CurrentBranch() string {
+	if len(s.PendingBranches) > 0 {
+		return s.PendingBranches[0]
+	}
+	return s.Branch
+}
+
+// GetRebaseState returns the state of the in-progress rebase, or false if no rebase is in progress.
+func (g git) GetRebaseState() (RebaseState, bool, error) {
+	output, err := exec.Run("git", exec.WithArgs("rev-parse", "--path-format=absolute", "--git-path", "rebase-merge"))
+	if err != nil {
+		return RebaseState{}, false, fmt.Errorf("failed to get rebase dir, err: %v", err)
+	}
+	dir := output.Stdout
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return RebaseState{}, false, nil
+	} else if err != nil {
+		return RebaseState{}, false, fmt.Errorf("failed to read rebase dir, err: %v", err)
+	}
+
+	var state RebaseState
+	headName, err := readRebaseFile(dir, "head-name")
+	if err != nil {
+		return RebaseState{}, false, err
+	}
+	state.Branch, _ = strings.CutPrefix(headName, "refs/heads/")
+	if headName == "detached HEAD" {
+		state.Branch = ""
+	}
+	state.StoppedAt, err = readRebaseFile(dir, "stopped-sha")
+	if err != nil {
+		return RebaseState{}, false, err
+	}
+
+	// Lines are grouped in threes: the ref, its old commit, and its new commit once replayed.
+	updateRefs, err := readRebaseFile(dir, "update-refs")
+	if err != nil {
+		return RebaseState{}, false, err
+	}
+	lines := strings.Split(updateRefs, "\n")
+	for i := 0; i+2 < len(lines); i += 3 {
+		branch, ok := strings.CutPrefix(lines[i], "refs/heads/")
+		if ok && lines[i+2] != nullHash {
+			state.UpdatedBranches = append(state.UpdatedBranches, branch)
+		}
+	}
+
+	todo, err := readRebaseFile(dir, "git-rebase-todo")
+	if err != nil {
+		return RebaseState{}, false, err
+	}
+	for _, line := range strings.Split(todo, "\n") {
+		ref, ok := strings.CutPrefix(line, "update-ref ")
+		if !ok {
+			continue
+		}
+		if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			state.PendingBranches = append(state.PendingBranches, branch)
+		}
+	}
+	return state, true, nil
+}
+
+// GetConflictedFiles returns the files with unresolved merge conflicts.
+func (g git) GetConflictedFiles() ([]string, error) {
+	output, err := exec.Run("git", exec.WithArgs("diff", "--name-only", "--diff-filter=U"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conflicted files, err: %v", err)
+	}
+	return output.Lines(), nil
+}
+
+// RebaseContinue resumes the in-progress rebase. It runs interactively, so that git can open
+// an editor for the commit message of a resolved conflict.
+func (g git) RebaseContinue() error {
+	_, err := exec.Run("git", exec.WithArgs("rebase", "--continue"), exec.WithInteractive(true))
+	if err != nil {
+		return fmt.Errorf("failed to continue rebase, err: %v", err)
+	}
+	return nil
+}
+
+func (g git) RebaseAbort() error {
+	_, err := exec.Run("git", exec.WithArgs("rebase", "--abort"))
+	if err != nil {
+		return fmt.Errorf("failed to abort rebase, err: %v", err)
+	}
+	return nil
+}
+
+// CherryPick applies the commits in the range from..to on top of HEAD.
+func (g git) CherryPick(from string, to string) error {
+	_, err := exec.Run("git", exec.WithArgs("cherry-pick", "--allow-empty", fmt.Sprintf("%s..%s", from, to)))
+	if err != nil {
+		return fmt.Errorf("failed to cherry-pick %s..%s, err: %v", from, to, err)
+	}
+	return nil
+}
+
+// IsCherryPickInProgress returns whether a cherry-pick stopped partway, e.g. due to a conflict.
+func (g git) IsCherryPickInProgress() (bool, error) {
+	output, err := exec.Run("git", exec.WithArgs("rev-parse", "-q", "--verify", "CHERRY_PICK_HEAD"), exec.WithIgnoreExitError())
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a cherry-pick, err: %v", err)
+	}
+	if output.ExitCode == 0 {
+		return true, nil
+	}
+	// Cherry-picking a range of commits may also stop between commits.
+	output, err = exec.Run("git", exec.WithArgs("rev-parse", "--path-format=absolute", "--git-path", "sequencer"))
+	if err != nil {
+		return false, fmt.Errorf("failed to get sequencer dir, err: %v", err)
+	}
+	if _, err := os.Stat(output.Stdout); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read sequencer dir, err: %v", err)
+	}
+	return true, nil
+}
+
+func (g git) CherryPickAbort() error {
+	_, err := exec.Run("git", exec.WithArgs("cherry-pick", "--abort"))
+	if err != nil {
+		return fmt.Errorf("failed to abort cherry-pick, err: %v", err)
+	}
+	return nil
+}
+
+// readRebaseFile returns the trimmed contents of a file in the rebase dir, or "" if it doesn't exist.
+func readRebaseFile(dir string, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read rebase state %s, err: %v", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -1,11 +1,11 @@
 package sampleusage
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/raymondji/git-stack-cli/cleanup"
 	"github.com/raymondji/git-stack-cli/config"
 	"github.com/raymondji/git-stack-cli/exec"
 	"github.com/raymondji/git-stack-cli/githost"
@@ -130,51 +130,24 @@ func (s Sample) Execute() error {
 	return nil
 }
 
-func (s Sample) Cleanup() error {
+// Cleanup closes the change requests opened in repoPath and deletes the branches
+// created by the sample, both locally and in pushRemote.
+func (s Sample) Cleanup(repoPath string, pushRemote string) error {
 	if ok, err := s.git.IsRepoClean(); err != nil {
 		return err
 	} else if !ok {
 		return fmt.Errorf("aborting, git repo has changes")
 	}
 
-	remote, err := s.git.GetRemote()
-	if err != nil {
-		return err
-	}
-
 	if err := s.git.Checkout(s.defaultBranch); err != nil {
 		return err
 	}
 
-	return s.cleanupBranches(remote.URLPath, s.branchesToCleanup...)
-}
-
-func (s Sample) cleanupBranches(repoPath string, names ...string) error {
-	for _, name := range names {
-		hasPR := true
-		cr, err := s.host.GetChangeReqeuest(repoPath, name)
-		if errors.Is(err, githost.ErrDoesNotExist) {
-			hasPR = false
-		} else if err != nil {
-			return err
-		}
-
-		if hasPR {
-			_, err = s.host.CloseChangeRequest(repoPath, cr)
-			if err != nil {
-				return err
-			}
-		}
-
-		if err := s.git.DeleteBranchIfExists(name); err != nil {
-			return err
-		}
-		if err := s.git.DeleteRemoteBranchIfExists(name); err != nil {
-			return err
-		}
+	branches, err := cleanup.Plan(s.host, repoPath, s.branchesToCleanup...)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return cleanup.Run(s.git, s.host, repoPath, branches, cleanup.Opts{PushRemote: pushRemote})
 }
 
 func (s Sample) String() string {
@@ -30,3 +30,7 @@ func IndexFunc[S ~[]E, E any](s S, f func(E) bool) int {
 func Clone[S ~[]E, E any](s S) S {
 	return slices.Clone(s)
 }
+
+func Contains[S ~[]E, E comparable](s S, v E) bool {
+	return slices.Contains(s, v)
+}
@@ -0,0 +1,274 @@
+// Package snapshot records the tips of all local branches before git stack rewrites them,
+// so that operations can be undone in one step.
+package snapshot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+)
+
+const (
+	refPrefix = "refs/git-stack/snapshots/"
+	// Older snapshots are deleted once there are more than this many.
+	maxSnapshots = 50
+)
+
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// The branch that was checked out, empty if HEAD was detached.
+	Head string `json:"head"`
+	// Keys are branch names, values are commit hashes.
+	Branches map[string]string `json:"branches"`
+	// Keys are branch names, values are the configured stack bases (branch.<name>.stackBase).
+	Bases map[string]string `json:"bases,omitempty"`
+	// Set if this snapshot was taken by undoing another snapshot.
+	Undoes string `json:"undoes,omitempty"`
+}
+
+// Take records the current tip of every local branch. The commits are kept alive by refs
+// under refs/git-stack/snapshots/<id>/, and the snapshot is appended to the oplog.
+func Take(git libgit.Git, command string) (Snapshot, error) {
+	return take(git, command, "")
+}
+
+func take(git libgit.Git, command string, undoes string) (Snapshot, error) {
+	branches, err := git.GetBranchHashes()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	bases, err := git.GetStackBases()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	head, err := git.GetCurrentBranch()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if head == "HEAD" {
+		head = ""
+	}
+
+	now := time.Now()
+	s := Snapshot{
+		ID:       strconv.FormatInt(now.UnixMilli(), 10),
+		Time:     now,
+		Command:  command,
+		Head:     head,
+		Branches: branches,
+		Bases:    bases,
+		Undoes:   undoes,
+	}
+	var updates []libgit.RefUpdate
+	for b, hash := range branches {
+		updates = append(updates, libgit.RefUpdate{Ref: refPrefix + s.ID + "/" + b, Hash: hash})
+	}
+	if err := git.UpdateRefs(updates); err != nil {
+		return Snapshot{}, err
+	}
+	if err := appendOplog(git, s); err != nil {
+		return Snapshot{}, err
+	}
+	if err := prune(git); err != nil {
+		return Snapshot{}, err
+	}
+	return s, nil
+}
+
+// List returns all snapshots, newest first.
+func List(git libgit.Git) ([]Snapshot, error) {
+	path, err := oplogPath(git)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open oplog, err: %v", err)
+	}
+	defer f.Close()
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("failed to parse oplog entry %q, err: %v", scanner.Text(), err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read oplog, err: %v", err)
+	}
+	slices.Reverse(snapshots)
+	return snapshots, nil
+}
+
+// LatestUndoable returns the most recent snapshot that hasn't been undone, skipping
+// snapshots taken by undo itself so that repeated undos keep stepping back in history.
+// Snapshots are expected to be ordered newest first.
+func LatestUndoable(snapshots []Snapshot) (Snapshot, bool) {
+	undone := map[string]struct{}{}
+	for _, s := range snapshots {
+		if s.Undoes != "" {
+			undone[s.Undoes] = struct{}{}
+			continue
+		}
+		if _, ok := undone[s.ID]; ok {
+			continue
+		}
+		return s, true
+	}
+	return Snapshot{}, false
+}
+
+// Restore moves every branch in the snapshot back to its recorded tip and stack base,
+// recreating any deleted branches, and checks out the branch that was checked out at the time. A new
+// snapshot is taken first, so the restore itself can be undone.
+// Branches created after the snapshot was taken are left alone.
+// Returns the branches that were changed.
+func Restore(git libgit.Git, s Snapshot, command string) ([]string, error) {
+	current, err := take(git, command, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	var updates []libgit.RefUpdate
+	for b, hash := range s.Branches {
+		if current.Branches[b] == hash {
+			continue
+		}
+		updates = append(updates, libgit.RefUpdate{Ref: "refs/heads/" + b, Hash: hash})
+		changed = append(changed, b)
+	}
+	if err := git.UpdateRefs(updates); err != nil {
+		return nil, err
+	}
+	slices.Sort(changed)
+	for b := range s.Branches {
+		base, ok := s.Bases[b]
+		if base == current.Bases[b] {
+			continue
+		}
+		if ok {
+			err = git.SetStackBase(b, base)
+		} else {
+			err = git.UnsetStackBase(b)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The repo is expected to be clean, this only updates the working tree if the
+	// current branch was moved above.
+	if err := git.ResetHard("HEAD"); err != nil {
+		return nil, err
+	}
+	if s.Head != "" && s.Head != current.Head {
+		if err := git.Checkout(s.Head); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+func appendOplog(git libgit.Git, s Snapshot) error {
+	path, err := oplogPath(git)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create oplog dir, err: %v", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot, err: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open oplog, err: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write oplog, err: %v", err)
+	}
+	return nil
+}
+
+// prune deletes the oldest snapshots beyond maxSnapshots.
+func prune(git libgit.Git) error {
+	snapshots, err := List(git)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) <= maxSnapshots {
+		return nil
+	}
+
+	keep := map[string]struct{}{}
+	for _, s := range snapshots[:maxSnapshots] {
+		keep[s.ID] = struct{}{}
+	}
+	refs, err := git.GetRefs(refPrefix)
+	if err != nil {
+		return err
+	}
+	var deletes []libgit.RefUpdate
+	for _, ref := range refs {
+		id, _, _ := strings.Cut(strings.TrimPrefix(ref, refPrefix), "/")
+		if _, ok := keep[id]; ok {
+			continue
+		}
+		deletes = append(deletes, libgit.RefUpdate{Ref: ref})
+	}
+	if err := git.UpdateRefs(deletes); err != nil {
+		return err
+	}
+
+	path, err := oplogPath(git)
+	if err != nil {
+		return err
+	}
+	kept := slices.Clone(snapshots[:maxSnapshots])
+	slices.Reverse(kept)
+	var data []byte
+	for _, s := range kept {
+		line, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("failed to marshal snapshot, err: %v", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write oplog, err: %v", err)
+	}
+	return nil
+}
+
+func oplogPath(git libgit.Git) (string, error) {
+	dir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-stack", "oplog"), nil
+}
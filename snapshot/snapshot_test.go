@@ -0,0 +1,44 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/raymondji/git-stack-cli/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestUndoable(t *testing.T) {
+	cases := map[string]struct {
+		snapshots []snapshot.Snapshot
+		wantID    string
+		wantOK    bool
+	}{
+		"no snapshots": {},
+		"latest": {
+			snapshots: []snapshot.Snapshot{{ID: "2"}, {ID: "1"}},
+			wantID:    "2",
+			wantOK:    true,
+		},
+		"skips undone": {
+			snapshots: []snapshot.Snapshot{{ID: "3", Undoes: "2"}, {ID: "2"}, {ID: "1"}},
+			wantID:    "1",
+			wantOK:    true,
+		},
+		"repeated undos": {
+			snapshots: []snapshot.Snapshot{{ID: "4", Undoes: "1"}, {ID: "3", Undoes: "2"}, {ID: "2"}, {ID: "1"}},
+		},
+		"new command after undo": {
+			snapshots: []snapshot.Snapshot{{ID: "4"}, {ID: "3", Undoes: "2"}, {ID: "2"}, {ID: "1"}},
+			wantID:    "4",
+			wantOK:    true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := snapshot.LatestUndoable(c.snapshots)
+			require.Equal(t, c.wantOK, ok)
+			require.Equal(t, c.wantID, got.ID)
+		})
+	}
+}
@@ -76,3 +76,38 @@ func Compute(log libgit.Log) (DAG, error) {
 
 	return dag, nil
 }
+
+// Condense returns the edges between the commits that have branches or that history forks from,
+// skipping over the commits in between. Keys are children, values are parents, as in DAG.ParentEdges.
+// Commits without such an ancestor in the DAG have no parents.
+func Condense(dag DAG) map[string]map[string]struct{} {
+	isKept := func(hash string) bool {
+		return len(dag.Nodes[hash].LocalBranches) > 0 || len(dag.ChildrenEdges[hash]) > 1
+	}
+
+	edges := map[string]map[string]struct{}{}
+	for hash := range dag.Nodes {
+		if !isKept(hash) {
+			continue
+		}
+		parents := map[string]struct{}{}
+		visited := map[string]struct{}{}
+		var walk func(h string)
+		walk = func(h string) {
+			for p := range dag.ParentEdges[h] {
+				if _, ok := visited[p]; ok {
+					continue
+				}
+				visited[p] = struct{}{}
+				if isKept(p) {
+					parents[p] = struct{}{}
+				} else {
+					walk(p)
+				}
+			}
+		}
+		walk(hash)
+		edges[hash] = parents
+	}
+	return edges
+}
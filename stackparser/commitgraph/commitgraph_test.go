@@ -231,3 +231,68 @@ func TestCompute(t *testing.T) {
 		})
 	}
 }
+
+func TestCondense(t *testing.T) {
+	cases := map[string]struct {
+		log  libgit.Log
+		want map[string]map[string]struct{}
+	}{
+		"empty": {
+			log:  libgit.Log{},
+			want: map[string]map[string]struct{}{},
+		},
+		"skips commits without branches": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{Hash: "c4", ParentHashes: []string{"c3"}, LocalBranches: []string{"feat/pt2"}},
+					{Hash: "c3", ParentHashes: []string{"c2"}},
+					{Hash: "c2", ParentHashes: []string{"c1"}, LocalBranches: []string{"feat/pt1"}},
+					{Hash: "c1", ParentHashes: []string{"c0"}},
+				},
+			},
+			want: map[string]map[string]struct{}{
+				"c4": {"c2": {}},
+				"c2": {},
+			},
+		},
+		"keeps fork points": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{Hash: "c4", ParentHashes: []string{"c2"}, LocalBranches: []string{"featB"}},
+					{Hash: "c3", ParentHashes: []string{"c2"}, LocalBranches: []string{"featA"}},
+					{Hash: "c2", ParentHashes: []string{"c1"}},
+					{Hash: "c1", ParentHashes: []string{"c0"}, LocalBranches: []string{"base"}},
+				},
+			},
+			want: map[string]map[string]struct{}{
+				"c4": {"c2": {}},
+				"c3": {"c2": {}},
+				"c2": {"c1": {}},
+				"c1": {},
+			},
+		},
+		"merges": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{Hash: "c4", ParentHashes: []string{"c3", "c2"}, LocalBranches: []string{"merged"}},
+					{Hash: "c3", ParentHashes: []string{"c1"}},
+					{Hash: "c2", ParentHashes: []string{"c0"}, LocalBranches: []string{"other"}},
+					{Hash: "c1", ParentHashes: []string{"c0"}, LocalBranches: []string{"feat"}},
+				},
+			},
+			want: map[string]map[string]struct{}{
+				"c4": {"c1": {}, "c2": {}},
+				"c2": {},
+				"c1": {},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dag, err := commitgraph.Compute(c.log)
+			require.NoError(t, err)
+			require.Equal(t, c.want, commitgraph.Condense(dag))
+		})
+	}
+}
@@ -13,6 +13,10 @@ import (
 type Stack struct {
 	Name    string
 	Commits map[string]*Commit // Guaranteed to not be empty
+	// The branch the stack is based on. Only set when parsing with WithBases.
+	Base string
+	// Keys are the names of other stacks that fork from this stack at a commit without a branch.
+	divergesFrom map[string]struct{}
 }
 
 type Commit struct {
@@ -100,18 +104,13 @@ func (s Stack) TotalOrderedBranches() ([]string, error) {
 	return branches, nil
 }
 
+// DivergesFrom returns the names of other stacks that fork from this stack at a commit without
+// a branch. Stacks that fork at a branch are valid, and form a tree (see GetTree).
 func (s Stack) DivergesFrom() map[string]struct{} {
-	name := s.Name
 	divergesFrom := map[string]struct{}{}
-	for _, c := range s.Commits {
-		for stackName := range c.StackBranchScore {
-			if stackName == name {
-				continue
-			}
-			divergesFrom[stackName] = struct{}{}
-		}
+	for name := range s.divergesFrom {
+		divergesFrom[name] = struct{}{}
 	}
-
 	return divergesFrom
 }
 
@@ -152,8 +151,47 @@ type dag struct {
 	parentEdges map[string]map[string]struct{}
 }
 
+type parseOpts struct {
+	excludedBranches map[string]struct{}
+	defaultBase      string
+	bases            map[string]string
+}
+
+type parseOpt func(*parseOpts)
+
+// WithExcludedBranches parses stacks as if the given branches did not exist,
+// e.g. for branches that were squash merged. Commits that are only reachable
+// from excluded branches are dropped.
+func WithExcludedBranches(branches ...string) parseOpt {
+	return func(opts *parseOpts) {
+		for _, b := range branches {
+			opts.excludedBranches[b] = struct{}{}
+		}
+	}
+}
+
+// WithBases sets the base of each stack. Keys of bases are branch names, values are the
+// branch they're based on. Stacks use the base of their bottom-most branch that has one,
+// or defaultBase if none do.
+func WithBases(defaultBase string, bases map[string]string) parseOpt {
+	return func(opts *parseOpts) {
+		opts.defaultBase = defaultBase
+		opts.bases = bases
+	}
+}
+
 // ParseStacks parses commit stacks from the git commit log
-func ParseStacks(log libgit.Log) ([]Stack, error) {
+func ParseStacks(log libgit.Log, fOpts ...parseOpt) ([]Stack, error) {
+	opts := parseOpts{
+		excludedBranches: map[string]struct{}{},
+	}
+	for _, o := range fOpts {
+		o(&opts)
+	}
+	if len(opts.excludedBranches) > 0 {
+		log = excludeBranches(log, opts.excludedBranches)
+	}
+
 	rawGraph, err := commitgraph.Compute(log)
 	if err != nil {
 		return nil, err
@@ -190,13 +228,122 @@ func ParseStacks(log libgit.Log) ([]Stack, error) {
 		if err != nil {
 			return nil, err
 		}
+		if opts.defaultBase != "" {
+			stack.Base = getBase(stack, opts.defaultBase, opts.bases)
+		}
 		stacks = append(stacks, stack)
 	}
 	sortStacks(stacks)
+	markDivergentStacks(stacks, rawGraph)
 
 	return stacks, nil
 }
 
+// ParseGraph computes the commit graph that stacks are parsed from. Only the
+// excluded branches option applies.
+func ParseGraph(log libgit.Log, fOpts ...parseOpt) (commitgraph.DAG, error) {
+	opts := parseOpts{
+		excludedBranches: map[string]struct{}{},
+	}
+	for _, o := range fOpts {
+		o(&opts)
+	}
+	if len(opts.excludedBranches) > 0 {
+		log = excludeBranches(log, opts.excludedBranches)
+	}
+	return commitgraph.Compute(log)
+}
+
+// markDivergentStacks records stacks that fork from each other at a commit without a branch.
+// There is no branch for the forked stacks to be stacked on top of in that case.
+func markDivergentStacks(stacks []Stack, graph commitgraph.DAG) {
+	for i, s := range stacks {
+		for hash, c := range s.Commits {
+			if len(c.LocalBranches) > 0 {
+				continue
+			}
+			for childHash := range graph.ChildrenEdges[hash] {
+				if _, ok := s.Commits[childHash]; ok {
+					continue
+				}
+				for _, other := range stacks {
+					if _, ok := other.Commits[childHash]; !ok {
+						continue
+					}
+					if stacks[i].divergesFrom == nil {
+						stacks[i].divergesFrom = map[string]struct{}{}
+					}
+					stacks[i].divergesFrom[other.Name] = struct{}{}
+				}
+			}
+		}
+	}
+}
+
+func getBase(stack Stack, defaultBase string, bases map[string]string) string {
+	base := defaultBase
+	var bottomScore int
+	for _, c := range stack.Commits {
+		score := c.StackBranchScore[stack.Name]
+		for _, b := range c.LocalBranches {
+			branchBase, ok := bases[b]
+			if !ok {
+				continue
+			}
+			// Higher scores are lower in the stack. Break ties deterministically.
+			if score > bottomScore || (score == bottomScore && branchBase < base) {
+				base = branchBase
+				bottomScore = score
+			}
+		}
+	}
+	return base
+}
+
+func excludeBranches(log libgit.Log, excluded map[string]struct{}) libgit.Log {
+	commits := map[string]libgit.Commit{}
+	for _, c := range log.Commits {
+		var branches []string
+		for _, b := range c.LocalBranches {
+			if _, ok := excluded[b]; !ok {
+				branches = append(branches, b)
+			}
+		}
+		c.LocalBranches = branches
+		commits[c.Hash] = c
+	}
+
+	// Keep only the commits reachable from a remaining branch.
+	reachable := map[string]struct{}{}
+	var visit func(hash string)
+	visit = func(hash string) {
+		c, ok := commits[hash]
+		if !ok {
+			return
+		}
+		if _, ok := reachable[hash]; ok {
+			return
+		}
+		reachable[hash] = struct{}{}
+		for _, ph := range c.ParentHashes {
+			visit(ph)
+		}
+	}
+	for _, c := range log.Commits {
+		if len(commits[c.Hash].LocalBranches) > 0 {
+			visit(c.Hash)
+		}
+	}
+
+	var out libgit.Log
+	for _, c := range log.Commits {
+		if _, ok := reachable[c.Hash]; ok {
+			out.Commits = append(out.Commits, commits[c.Hash])
+		}
+	}
+	return out
+}
+
 func sortStacks(stacks []Stack) {
 	slices.SortFunc(stacks, func(a, b Stack) int {
 		if a.Name < b.Name {
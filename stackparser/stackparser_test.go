@@ -12,6 +12,7 @@ import (
 func TestInferStacks(t *testing.T) {
 	cases := map[string]struct {
 		log                            libgit.Log
+		excludedBranches               []string
 		commitHashToContainingBranches map[string][]string
 		want                           []stackparser.Stack
 		// Keys are stack names
@@ -432,11 +433,95 @@ func TestInferStacks(t *testing.T) {
 				"featB": {"featB", "featA"},
 			},
 		},
+		"excluded branch at bottom of stack": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{
+						Hash:          "c2",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featA/pt2"},
+					},
+					{
+						Hash:          "c1",
+						ParentHashes:  []string{"c0"},
+						LocalBranches: []string{"featA/pt1"},
+					},
+				},
+			},
+			excludedBranches: []string{"featA/pt1"},
+			want: []stackparser.Stack{
+				{
+					Name: "featA/pt2",
+					Commits: map[string]*stackparser.Commit{
+						"c2": {
+							Node: commitgraph.Node{
+								Hash:          "c2",
+								LocalBranches: []string{"featA/pt2"},
+							},
+							StackBranchScore: map[string]int{
+								"featA/pt2": 1,
+							},
+						},
+						"c1": {
+							Node: commitgraph.Node{
+								Hash: "c1",
+							},
+							StackBranchScore: map[string]int{
+								"featA/pt2": 0,
+							},
+						},
+					},
+				},
+			},
+			wantTotalOrderedBranches: map[string][]string{
+				"featA/pt2": {"featA/pt2"},
+			},
+		},
+		"excluded branch at top of stack": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{
+						Hash:          "c3",
+						ParentHashes:  []string{"c2"},
+						LocalBranches: []string{"featA/pt2"},
+					},
+					{
+						Hash:         "c2",
+						ParentHashes: []string{"c1"},
+					},
+					{
+						Hash:          "c1",
+						ParentHashes:  []string{"c0"},
+						LocalBranches: []string{"featA/pt1"},
+					},
+				},
+			},
+			excludedBranches: []string{"featA/pt2"},
+			want: []stackparser.Stack{
+				{
+					Name: "featA/pt1",
+					Commits: map[string]*stackparser.Commit{
+						"c1": {
+							Node: commitgraph.Node{
+								Hash:          "c1",
+								LocalBranches: []string{"featA/pt1"},
+							},
+							StackBranchScore: map[string]int{
+								"featA/pt1": 1,
+							},
+						},
+					},
+				},
+			},
+			wantTotalOrderedBranches: map[string][]string{
+				"featA/pt1": {"featA/pt1"},
+			},
+		},
 	}
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := stackparser.ParseStacks(c.log)
+			got, err := stackparser.ParseStacks(c.log, stackparser.WithExcludedBranches(c.excludedBranches...))
 			require.NoError(t, err)
 			require.Equal(t, c.want, got)
 
@@ -460,3 +545,92 @@ func TestInferStacks(t *testing.T) {
 		})
 	}
 }
+
+func TestWithBases(t *testing.T) {
+	log := libgit.Log{
+		Commits: []libgit.Commit{
+			{
+				Hash:          "c4",
+				ParentHashes:  []string{"c3"},
+				LocalBranches: []string{"featB/pt2"},
+			},
+			{
+				Hash:          "c3",
+				ParentHashes:  []string{"c0"},
+				LocalBranches: []string{"featB/pt1"},
+			},
+			{
+				Hash:          "c2",
+				ParentHashes:  []string{"c1"},
+				LocalBranches: []string{"featA/pt2"},
+			},
+			{
+				Hash:          "c1",
+				ParentHashes:  []string{"c0"},
+				LocalBranches: []string{"featA/pt1"},
+			},
+		},
+	}
+	cases := map[string]struct {
+		bases map[string]string
+		// Keys are stack names
+		want map[string]string
+	}{
+		"no bases": {
+			bases: map[string]string{},
+			want: map[string]string{
+				"featA/pt2": "main",
+				"featB/pt2": "main",
+			},
+		},
+		"base on bottom branch": {
+			bases: map[string]string{
+				"featA/pt1": "release/1.0",
+			},
+			want: map[string]string{
+				"featA/pt2": "release/1.0",
+				"featB/pt2": "main",
+			},
+		},
+		"bottom branch takes precedence": {
+			bases: map[string]string{
+				"featB/pt1": "release/1.0",
+				"featB/pt2": "release/2.0",
+			},
+			want: map[string]string{
+				"featA/pt2": "main",
+				"featB/pt2": "release/1.0",
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			stacks, err := stackparser.ParseStacks(log, stackparser.WithBases("main", c.bases))
+			require.NoError(t, err)
+
+			got := map[string]string{}
+			for _, s := range stacks {
+				got[s.Name] = s.Base
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestParseGraph(t *testing.T) {
+	log := libgit.Log{
+		Commits: []libgit.Commit{
+			{Hash: "c3", ParentHashes: []string{"c2"}, LocalBranches: []string{"featB"}},
+			{Hash: "c2", ParentHashes: []string{"c1"}, LocalBranches: []string{"featA/pt2"}},
+			{Hash: "c1", ParentHashes: []string{"c0"}, LocalBranches: []string{"featA/pt1"}},
+		},
+	}
+
+	dag, err := stackparser.ParseGraph(log, stackparser.WithExcludedBranches("featA/pt1", "featB"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]commitgraph.Node{
+		"c2": {Hash: "c2", LocalBranches: []string{"featA/pt2"}},
+		"c1": {Hash: "c1"},
+	}, dag.Nodes)
+}
@@ -0,0 +1,140 @@
+package stackparser
+
+import (
+	"errors"
+	"slices"
+)
+
+// Tree is a set of stacks that share branches, e.g. two stacks built on top of the same
+// base branch. The branches in a tree form a tree rooted at the default branch.
+type Tree struct {
+	// Guaranteed to not be empty
+	Stacks []Stack
+}
+
+// GetTree returns the tree containing the given stack.
+func GetTree(stacks []Stack, stack Stack) Tree {
+	inTree := map[string]struct{}{stack.Name: {}}
+	branches := map[string]struct{}{}
+	for _, b := range stack.Branches() {
+		branches[b] = struct{}{}
+	}
+
+	// Keep adding stacks that share a branch with the tree until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for _, s := range stacks {
+			if _, ok := inTree[s.Name]; ok {
+				continue
+			}
+			if !slices.ContainsFunc(s.Branches(), func(b string) bool {
+				_, ok := branches[b]
+				return ok
+			}) {
+				continue
+			}
+
+			inTree[s.Name] = struct{}{}
+			for _, b := range s.Branches() {
+				branches[b] = struct{}{}
+			}
+			changed = true
+		}
+	}
+
+	var tree Tree
+	for _, s := range stacks {
+		if _, ok := inTree[s.Name]; ok {
+			tree.Stacks = append(tree.Stacks, s)
+		}
+	}
+	if len(tree.Stacks) == 0 {
+		tree.Stacks = []Stack{stack}
+	}
+	return tree
+}
+
+// GetCurrentTree returns the tree containing the current commit.
+func GetCurrentTree(stacks []Stack, currCommit string) (Tree, error) {
+	for _, s := range stacks {
+		if s.IsCurrent(currCommit) {
+			// Any other stacks containing the current commit share it with this stack,
+			// so they are part of the same tree.
+			return GetTree(stacks, s), nil
+		}
+	}
+	return Tree{}, errors.New("unable to infer current stack")
+}
+
+// Base returns the branch the tree is based on. Stacks in a tree share their bottom
+// branches, so they are expected to have the same base.
+func (t Tree) Base() string {
+	return t.Stacks[0].Base
+}
+
+// IsLinear returns whether the tree consists of a single stack.
+func (t Tree) IsLinear() bool {
+	return len(t.Stacks) == 1
+}
+
+// Branches returns all branches in the tree in reverse lexicographic order.
+func (t Tree) Branches() []string {
+	seen := map[string]struct{}{}
+	var branches []string
+	for _, s := range t.Stacks {
+		for _, b := range s.Branches() {
+			if _, ok := seen[b]; ok {
+				continue
+			}
+			seen[b] = struct{}{}
+			branches = append(branches, b)
+		}
+	}
+	slices.Sort(branches)
+	slices.Reverse(branches)
+	return branches
+}
+
+// Parents returns the branch each branch is stacked on top of. Branches at the bottom of the
+// tree have an empty parent. If any stack in the tree is invalid, returns NoTotalOrderError.
+func (t Tree) Parents() (map[string]string, error) {
+	parents := map[string]string{}
+	for _, s := range t.Stacks {
+		branches, err := s.TotalOrderedBranches()
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range branches {
+			if i == len(branches)-1 {
+				parents[b] = ""
+			} else {
+				parents[b] = branches[i+1]
+			}
+		}
+	}
+	return parents, nil
+}
+
+// Children returns the inverse of parents, with children in lexicographic order.
+// The branches at the bottom of the tree are keyed by the empty string.
+func Children(parents map[string]string) map[string][]string {
+	children := map[string][]string{}
+	for b, p := range parents {
+		children[p] = append(children[p], b)
+	}
+	for _, c := range children {
+		slices.Sort(c)
+	}
+	return children
+}
+
+// DivergesFrom returns the names of the stacks that any stack in the tree diverges from.
+func (t Tree) DivergesFrom() map[string]struct{} {
+	divergesFrom := map[string]struct{}{}
+	for _, s := range t.Stacks {
+		for name := range s.DivergesFrom() {
+			divergesFrom[name] = struct{}{}
+		}
+	}
+	return divergesFrom
+}
@@ -0,0 +1,133 @@
+package stackparser_test
+
+import (
+	"testing"
+
+	"github.com/raymondji/git-stack-cli/libgit"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTree(t *testing.T) {
+	cases := map[string]struct {
+		log       libgit.Log
+		stackName string
+		// Names of the stacks in the tree
+		wantStacks       []string
+		wantParents      map[string]string
+		wantDivergesFrom map[string]struct{}
+	}{
+		"linear": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{
+						Hash:          "c2",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featA/pt2"},
+					},
+					{
+						Hash:          "c1",
+						ParentHashes:  []string{"c0"},
+						LocalBranches: []string{"featA/pt1"},
+					},
+				},
+			},
+			stackName:  "featA/pt2",
+			wantStacks: []string{"featA/pt2"},
+			wantParents: map[string]string{
+				"featA/pt2": "featA/pt1",
+				"featA/pt1": "",
+			},
+			wantDivergesFrom: map[string]struct{}{},
+		},
+		"fork at branch": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{
+						Hash:          "c4",
+						ParentHashes:  []string{"c0"},
+						LocalBranches: []string{"featD"},
+					},
+					{
+						Hash:          "c3",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featC"},
+					},
+					{
+						Hash:          "c2",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featB"},
+					},
+					{
+						Hash:          "c1",
+						ParentHashes:  []string{"c0"},
+						LocalBranches: []string{"featA"},
+					},
+				},
+			},
+			stackName:  "featB",
+			wantStacks: []string{"featB", "featC"},
+			wantParents: map[string]string{
+				"featC": "featA",
+				"featB": "featA",
+				"featA": "",
+			},
+			wantDivergesFrom: map[string]struct{}{},
+		},
+		"fork at commit without branch": {
+			log: libgit.Log{
+				Commits: []libgit.Commit{
+					{
+						Hash:          "c3",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featC"},
+					},
+					{
+						Hash:          "c2",
+						ParentHashes:  []string{"c1"},
+						LocalBranches: []string{"featB"},
+					},
+					{
+						Hash:         "c1",
+						ParentHashes: []string{"c0"},
+					},
+				},
+			},
+			stackName:  "featB",
+			wantStacks: []string{"featB"},
+			wantParents: map[string]string{
+				"featB": "",
+			},
+			wantDivergesFrom: map[string]struct{}{
+				"featC": {},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			stacks, err := stackparser.ParseStacks(c.log)
+			require.NoError(t, err)
+
+			var stack stackparser.Stack
+			for _, s := range stacks {
+				if s.Name == c.stackName {
+					stack = s
+				}
+			}
+			require.Equal(t, c.stackName, stack.Name)
+
+			tree := stackparser.GetTree(stacks, stack)
+			var gotStacks []string
+			for _, s := range tree.Stacks {
+				gotStacks = append(gotStacks, s.Name)
+			}
+			require.Equal(t, c.wantStacks, gotStacks)
+
+			gotParents, err := tree.Parents()
+			require.NoError(t, err)
+			require.Equal(t, c.wantParents, gotParents)
+			require.Equal(t, c.wantDivergesFrom, tree.DivergesFrom())
+		})
+	}
+}
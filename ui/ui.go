@@ -5,6 +5,8 @@ import (
 
 	"github.com/raymondji/git-stack-cli/config"
 	"github.com/raymondji/git-stack-cli/githost"
+	"github.com/raymondji/git-stack-cli/stackparser"
+	"golang.org/x/exp/maps"
 )
 
 func PrintBranchesInStack(
@@ -53,6 +55,73 @@ func PrintBranchesInStack(
 		}
 	}
 
+	printMissingPRs(branches, prsBySourceBranch, showPRs, vocab)
+}
+
+// PrintBranchTree prints branches that are stacked as a tree, starting from the bottom of the tree.
+// Keys of parents are branch names, values are the branch they're stacked on (see stackparser.Tree).
+func PrintBranchTree(
+	parents map[string]string,
+	currBranch string,
+	theme config.Theme,
+	prsBySourceBranch map[string]githost.PullRequest,
+	showPRs bool,
+	vocab githost.Vocabulary,
+) {
+	children := stackparser.Children(parents)
+
+	var printBranch func(branch string, prefix string, connector string, childPrefix string)
+	printBranch = func(branch string, prefix string, connector string, childPrefix string) {
+		isTop := len(children[branch]) == 0
+		var hereMarker, branchesSegment, suffix string
+		if isTop {
+			suffix = fmt.Sprintf(" (%s)", theme.TertiaryColor.Render("top"))
+		}
+		if branch == currBranch {
+			hereMarker = "*"
+			branchesSegment = theme.PrimaryColor.Render(branch)
+		} else if isTop {
+			hereMarker = " "
+			branchesSegment = theme.TertiaryColor.Render(branch)
+		} else {
+			hereMarker = " "
+			branchesSegment = branch
+		}
+		fmt.Printf("%s %s%s%s%s\n", hereMarker, prefix, connector, branchesSegment, suffix)
+
+		if showPRs {
+			prPrefix := childPrefix + "    "
+			if !isTop {
+				prPrefix = childPrefix + "│   "
+			}
+			if pr, ok := prsBySourceBranch[branch]; ok {
+				fmt.Printf("  %s%s\n", prPrefix, pr.WebURL)
+			} else {
+				fmt.Printf("  %sNo %s\n", prPrefix, vocab.ChangeRequestName)
+			}
+		}
+
+		for i, child := range children[branch] {
+			if i == len(children[branch])-1 {
+				printBranch(child, childPrefix, "└── ", childPrefix+"    ")
+			} else {
+				printBranch(child, childPrefix, "├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	for _, root := range children[""] {
+		printBranch(root, "", "", "")
+	}
+
+	printMissingPRs(maps.Keys(parents), prsBySourceBranch, showPRs, vocab)
+}
+
+func printMissingPRs(
+	branches []string,
+	prsBySourceBranch map[string]githost.PullRequest,
+	showPRs bool,
+	vocab githost.Vocabulary,
+) {
 	var missingPRs bool
 	for _, b := range branches {
 		_, ok := prsBySourceBranch[b]